@@ -8,15 +8,18 @@
 package corp
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
 	"sync"
 	"time"
+
+	"github.com/c77cc/wechat/util"
 )
 
 // access_token 中控服务器接口, see access_token_server.png
@@ -38,15 +41,21 @@ var _ AccessTokenServer = (*DefaultAccessTokenServer)(nil)
 
 // AccessTokenServer 的简单实现.
 //  NOTE:
-//  1. 用于单进程环境.
+//  1. 不指定 TokenStore 的时候用于单进程环境;
 //  2. 因为 DefaultAccessTokenServer 同时也是一个简单的中控服务器, 而不是仅仅实现 AccessTokenServer 接口,
-//     所以整个系统只能存在一个 DefaultAccessTokenServer 实例!
+//     所以不指定 TokenStore 的时候整个系统只能存在一个 DefaultAccessTokenServer 实例!
+//  3. 多进程环境下请用 NewDefaultAccessTokenServerWithStore 创建, 所有进程共享同一个 TokenStore,
+//     同一时刻只有一个进程去微信服务器刷新 access_token.
 type DefaultAccessTokenServer struct {
 	corpId     string
 	corpSecret string
 	httpClient *http.Client
+	endpoint   *util.Endpoint // 见 SetEndpoint
+
+	tokenStore    util.TokenStore // 多进程共享 access_token 的存储, 可以为 nil
+	tokenStoreKey string
 
-	resetTickerChan chan time.Duration // 用于重置 tokenDaemon 里的 ticker
+	refresher *util.RefreshScheduler // 定时刷新 access_token
 
 	tokenGet struct {
 		sync.Mutex
@@ -56,7 +65,8 @@ type DefaultAccessTokenServer struct {
 
 	tokenCache struct {
 		sync.RWMutex
-		Token string
+		Token     string
+		ExpiresAt time.Time
 	}
 }
 
@@ -65,27 +75,42 @@ type DefaultAccessTokenServer struct {
 func NewDefaultAccessTokenServer(corpId, corpSecret string,
 	httpClient *http.Client) (srv *DefaultAccessTokenServer) {
 
+	return NewDefaultAccessTokenServerWithStore(corpId, corpSecret, nil, httpClient)
+}
+
+// 创建一个新的 DefaultAccessTokenServer, 通过 store 和其他进程共享 access_token.
+//  如果 store == nil 则等价于 NewDefaultAccessTokenServer;
+//  如果 httpClient == nil 则默认使用 http.DefaultClient.
+func NewDefaultAccessTokenServerWithStore(corpId, corpSecret string, store util.TokenStore,
+	httpClient *http.Client) (srv *DefaultAccessTokenServer) {
+
 	if httpClient == nil {
 		httpClient = http.DefaultClient
 	}
 
+	// 同一个企业号不同的 corpSecret 对应不同的 access_token
+	secretHashsum := sha1.Sum([]byte(corpSecret))
+
 	srv = &DefaultAccessTokenServer{
-		corpId:          corpId,
-		corpSecret:      corpSecret,
-		httpClient:      httpClient,
-		resetTickerChan: make(chan time.Duration),
+		corpId:        corpId,
+		corpSecret:    corpSecret,
+		httpClient:    httpClient,
+		tokenStore:    store,
+		tokenStoreKey: "corp/access_token/" + corpId + "/" + hex.EncodeToString(secretHashsum[:]),
 	}
 
-	go srv.tokenDaemon(time.Hour * 24) // 启动 tokenDaemon
+	srv.refresher = util.NewRefreshScheduler(srv.tokenStoreKey, srv.refresh)
 	return
 }
 
 func (srv *DefaultAccessTokenServer) Token() (token string, err error) {
 	srv.tokenCache.RLock()
 	token = srv.tokenCache.Token
+	expiresAt := srv.tokenCache.ExpiresAt
 	srv.tokenCache.RUnlock()
 
-	if token != "" {
+	// 刷新失败的时候继续使用上一次获取的 access_token, 直到过期
+	if token != "" && time.Now().Before(expiresAt) {
 		return
 	}
 	return srv.TokenRefresh()
@@ -93,41 +118,32 @@ func (srv *DefaultAccessTokenServer) Token() (token string, err error) {
 
 func (srv *DefaultAccessTokenServer) TokenRefresh() (token string, err error) {
 	accessTokenInfo, cached, err := srv.getToken()
+	if !cached {
+		srv.refresher.Refreshed(time.Duration(accessTokenInfo.ExpiresIn)*time.Second, err)
+	}
 	if err != nil {
 		return
 	}
-	if !cached {
-		srv.resetTickerChan <- time.Duration(accessTokenInfo.ExpiresIn) * time.Second
-	}
 	token = accessTokenInfo.Token
 	return
 }
 
-func (srv *DefaultAccessTokenServer) tokenDaemon(tickDuration time.Duration) {
-NEW_TICK_DURATION:
-	ticker := time.NewTicker(tickDuration)
-
-	for {
-		select {
-		case tickDuration = <-srv.resetTickerChan:
-			ticker.Stop()
-			goto NEW_TICK_DURATION
-
-		case <-ticker.C:
-			accessTokenInfo, cached, err := srv.getToken()
-			if err != nil {
-				break
-			}
-			if !cached {
-				newTickDuration := time.Duration(accessTokenInfo.ExpiresIn) * time.Second
-				if tickDuration != newTickDuration {
-					tickDuration = newTickDuration
-					ticker.Stop()
-					goto NEW_TICK_DURATION
-				}
-			}
-		}
-	}
+// 停止定时刷新 access_token 的后台 goroutine, 等待其退出后返回, 可以重复调用.
+//  Close 之后 Token 和 TokenRefresh 仍然可用, 只是不再定时刷新.
+func (srv *DefaultAccessTokenServer) Close() error {
+	srv.refresher.Close()
+	return nil
+}
+
+// 设置定时刷新 access_token 的事件回调, hook == nil 的时候使用 util.DefaultRefreshHook.
+func (srv *DefaultAccessTokenServer) SetRefreshHook(hook util.RefreshHookFunc) {
+	srv.refresher.SetHook(hook)
+}
+
+// 由 refresher 定时调用.
+func (srv *DefaultAccessTokenServer) refresh() (expiresIn time.Duration, err error) {
+	info, _, err := srv.getToken()
+	return time.Duration(info.ExpiresIn) * time.Second, err
 }
 
 type accessTokenInfo struct {
@@ -155,23 +171,50 @@ func (srv *DefaultAccessTokenServer) getToken() (token accessTokenInfo, cached b
 		return
 	}
 
-	_url := "https://qyapi.weixin.qq.com/cgi-bin/gettoken?corpid=" + url.QueryEscape(srv.corpId) +
-		"&corpsecret=" + url.QueryEscape(srv.corpSecret)
+	var info accessTokenInfo
+	if srv.tokenStore != nil {
+		srv.tokenCache.RLock()
+		stale := srv.tokenCache.Token
+		srv.tokenCache.RUnlock()
+
+		info.Token, info.ExpiresIn, err = util.RefreshTokenWithStore(srv.tokenStore, srv.tokenStoreKey, stale,
+			func() (string, int64, error) {
+				info, err := srv.fetchToken()
+				return info.Token, info.ExpiresIn, err
+			})
+	} else {
+		info, err = srv.fetchToken()
+	}
+	if err != nil {
+		return
+	}
+
+	// 更新 tokenGet 信息
+	srv.tokenGet.LastTokenInfo = info
+	srv.tokenGet.LastTimestamp = timeNowUnix
+
+	// 更新缓存
+	srv.tokenCache.Lock()
+	srv.tokenCache.Token = info.Token
+	srv.tokenCache.ExpiresAt = time.Now().Add(time.Duration(info.ExpiresIn) * time.Second)
+	srv.tokenCache.Unlock()
+
+	token = info
+	return
+}
+
+// 从微信服务器获取 access_token.
+func (srv *DefaultAccessTokenServer) fetchToken() (token accessTokenInfo, err error) {
+	_url := srv.endpointURL("https://qyapi.weixin.qq.com/cgi-bin/gettoken?corpid=" + url.QueryEscape(srv.corpId) +
+		"&corpsecret=" + url.QueryEscape(srv.corpSecret))
 	httpResp, err := srv.httpClient.Get(_url)
 	if err != nil {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
 		return
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
-		err = fmt.Errorf("http.Status: %s", httpResp.Status)
+		err = &util.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 		return
 	}
 
@@ -182,9 +225,6 @@ func (srv *DefaultAccessTokenServer) getToken() (token accessTokenInfo, cached b
 
 	respBody, err := ioutil.ReadAll(httpResp.Body)
 	if err != nil {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
 		return
 	}
 
@@ -192,26 +232,15 @@ func (srv *DefaultAccessTokenServer) getToken() (token accessTokenInfo, cached b
 	LogInfoln("[WECHAT_DEBUG] response json:", string(respBody))
 
 	if err = json.Unmarshal(respBody, &result); err != nil {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
 		return
 	}
 
 	if result.ErrCode != ErrCodeOK {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
 		err = &result.Error
 		return
 	}
 
 	if result.ExpiresIn <= 60 {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
 		err = errors.New("expires_in too small: " + strconv.FormatInt(result.ExpiresIn, 10))
 		return
 	}
@@ -220,15 +249,6 @@ func (srv *DefaultAccessTokenServer) getToken() (token accessTokenInfo, cached b
 	// 所以这里故意增加1秒, 让其过期, 获取一个不同的 access_token.
 	result.ExpiresIn++
 
-	// 更新 tokenGet 信息
-	srv.tokenGet.LastTokenInfo = result.accessTokenInfo
-	srv.tokenGet.LastTimestamp = timeNowUnix
-
-	// 更新缓存
-	srv.tokenCache.Lock()
-	srv.tokenCache.Token = result.accessTokenInfo.Token
-	srv.tokenCache.Unlock()
-
 	token = result.accessTokenInfo
 	return
 }
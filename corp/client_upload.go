@@ -9,13 +9,15 @@ package corp
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"reflect"
+
+	"github.com/c77cc/wechat/util"
 )
 
 type MultipartFormField struct {
@@ -48,6 +50,11 @@ type MultipartFormField struct {
 //          ...
 //      }
 func (clt *CorpClient) PostMultipartForm(incompleteURL string, fields []MultipartFormField, response interface{}) (err error) {
+	return clt.PostMultipartFormContext(clt.Context(), incompleteURL, fields, response)
+}
+
+// PostMultipartForm 的 context 版本, ctx 用于取消请求或者设置请求的超时.
+func (clt *CorpClient) PostMultipartFormContext(ctx context.Context, incompleteURL string, fields []MultipartFormField, response interface{}) (err error) {
 	bodyBuf := mediaBufferPool.Get().(*bytes.Buffer)
 	bodyBuf.Reset()
 	defer mediaBufferPool.Put(bodyBuf)
@@ -86,18 +93,28 @@ func (clt *CorpClient) PostMultipartForm(incompleteURL string, fields []Multipar
 		return
 	}
 
+	retryPolicy := clt.retryPolicy()
+	attempt := 0
 	hasRetried := false
 RETRY:
-	finalURL := incompleteURL + url.QueryEscape(token)
+	attempt++
+	finalURL := clt.EndpointURL(incompleteURL) + url.QueryEscape(token)
 
-	httpResp, err := clt.HttpClient.Post(finalURL, multipartWriter.FormDataContentType(), bytes.NewReader(bodyBytes))
+	httpResp, err := util.HttpPostContext(ctx, clt.HttpClient, finalURL, multipartWriter.FormDataContentType(), bytes.NewReader(bodyBytes))
 	if err != nil {
 		return
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("http.Status: %s", httpResp.Status)
+		if retryPolicy.ShouldRetryHTTPStatus(ctx, attempt, httpResp.StatusCode) {
+			LogInfoln("[WECHAT_RETRY] http.Status:", httpResp.Status, ", attempt:", attempt)
+			if err = retryPolicy.Wait(ctx, attempt); err != nil {
+				return
+			}
+			goto RETRY
+		}
+		err = &util.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 		return
 	}
 
@@ -137,6 +154,14 @@ RETRY:
 		LogInfoln("[WECHAT_RETRY] fallthrough, current token:", token)
 		fallthrough
 	default:
+		if retryPolicy.ShouldRetry(ctx, attempt, ErrCode) {
+			LogInfoln("[WECHAT_RETRY] err_code:", ErrCode, ", attempt:", attempt)
+			if err = retryPolicy.Wait(ctx, attempt); err != nil {
+				return
+			}
+			responseStructValue.Set(reflect.New(responseStructValue.Type()).Elem())
+			goto RETRY
+		}
 		return
 	}
 }
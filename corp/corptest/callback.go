@@ -0,0 +1,213 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package corptest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/c77cc/wechat/corp"
+	"github.com/c77cc/wechat/corp/thirdparty"
+	"github.com/c77cc/wechat/util"
+)
+
+// 回调处理没有回复消息(回复了空串或者 "success").
+var ErrNoReply = errors.New("no reply message")
+
+// 模拟企业号的回调请求, 生成正确签名并且加密的 *http.Request, 并且验证和解密回复的消息.
+//  企业号应用和第三方应用套件的回调协议是一样的, 只是 ReceiverId 分别是 CorpId 和 SuiteId.
+//
+//  cb := corptest.NewAgentCallback(agentServer)
+//  var reply response.Text
+//  err := cb.Do(corp.NewAgentServerFrontend(agentServer, nil), &request.Text{...}, &reply)
+type Callback struct {
+	URL        string   // 回调地址, 默认为 "http://127.0.0.1/"; 可以带上 frontend 需要的查询参数
+	Token      string   // 应用或者套件的 Token
+	ReceiverId string   // 企业号应用为 CorpId, 第三方应用套件为 SuiteId
+	AESKey     [32]byte // 加密使用的 AES Key
+}
+
+// 根据 corp.AgentServer 的配置创建 Callback, 使用 CurrentAESKey 加密.
+func NewAgentCallback(srv corp.AgentServer) *Callback {
+	return &Callback{
+		Token:      srv.Token(),
+		ReceiverId: srv.CorpId(),
+		AESKey:     srv.CurrentAESKey(),
+	}
+}
+
+// 根据 thirdparty.SuiteServer 的配置创建 Callback, 使用 CurrentAESKey 加密.
+func NewSuiteCallback(srv thirdparty.SuiteServer) *Callback {
+	return &Callback{
+		Token:      srv.SuiteToken(),
+		ReceiverId: srv.SuiteId(),
+		AESKey:     srv.CurrentAESKey(),
+	}
+}
+
+// 创建首次验证的 GET 请求, 验证成功的话服务器会回复解密后的 echostr.
+func (cb *Callback) NewVerifyRequest(echostr string) (r *http.Request, err error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce, err := newNonce()
+	if err != nil {
+		return
+	}
+	encryptedEchostr, err := cb.encrypt([]byte(echostr))
+	if err != nil {
+		return
+	}
+
+	queryValues := url.Values{}
+	queryValues.Set("msg_signature", util.MsgSign(cb.Token, timestamp, nonce, encryptedEchostr))
+	queryValues.Set("timestamp", timestamp)
+	queryValues.Set("nonce", nonce)
+	queryValues.Set("echostr", encryptedEchostr)
+	return cb.newRequest("GET", queryValues, nil)
+}
+
+// 创建推送消息 msg 的 POST 请求.
+//  msg 是消息的数据结构, 比如 *request.Text, *menu.ClickEvent, 经过 encoding/xml marshal 后符合消息的格式.
+func (cb *Callback) NewRequest(msg interface{}) (r *http.Request, err error) {
+	rawXMLMsg, err := xml.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	// 企业号应用的 http body 需要带上和消息一致的 AgentID, 第三方应用套件的消息没有 AgentID
+	var requestHttpBody struct {
+		XMLName      struct{} `xml:"xml"`
+		ToUserName   string   `xml:"ToUserName"`
+		AgentId      int64    `xml:"AgentID,omitempty"`
+		EncryptedMsg string   `xml:"Encrypt"`
+	}
+	if err = xml.Unmarshal(rawXMLMsg, &requestHttpBody); err != nil {
+		return
+	}
+	if requestHttpBody.ToUserName == "" {
+		// 第三方应用套件的消息没有 ToUserName, http body 里的 ToUserName 为 SuiteId
+		requestHttpBody.ToUserName = cb.ReceiverId
+	}
+	if requestHttpBody.EncryptedMsg, err = cb.encrypt(rawXMLMsg); err != nil {
+		return
+	}
+	body, err := xml.Marshal(&requestHttpBody)
+	if err != nil {
+		return
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce, err := newNonce()
+	if err != nil {
+		return
+	}
+
+	queryValues := url.Values{}
+	queryValues.Set("msg_signature", util.MsgSign(cb.Token, timestamp, nonce, requestHttpBody.EncryptedMsg))
+	queryValues.Set("timestamp", timestamp)
+	queryValues.Set("nonce", nonce)
+	return cb.newRequest("POST", queryValues, body)
+}
+
+// 验证服务器回复的 http body 的 MsgSignature, 解密后解析到 v.
+func (cb *Callback) ParseResponse(body []byte, v interface{}) (err error) {
+	var responseHttpBody corp.ResponseHttpBody
+	if err = xml.Unmarshal(body, &responseHttpBody); err != nil {
+		return
+	}
+
+	timestamp := strconv.FormatInt(responseHttpBody.Timestamp, 10)
+	msgSignature := util.MsgSign(cb.Token, timestamp, responseHttpBody.Nonce, responseHttpBody.EncryptedMsg)
+	if responseHttpBody.MsgSignature != msgSignature {
+		return &util.SignatureError{Name: "MsgSignature", Input: responseHttpBody.MsgSignature, Local: msgSignature}
+	}
+
+	encryptedMsg, err := base64.StdEncoding.DecodeString(responseHttpBody.EncryptedMsg)
+	if err != nil {
+		return
+	}
+	_, rawXMLMsg, err := util.AESDecryptMsg(encryptedMsg, cb.ReceiverId, cb.AESKey)
+	if err != nil {
+		return
+	}
+	return xml.Unmarshal(rawXMLMsg, v)
+}
+
+// 把 msg 推送给 handler, 并且把回复的消息解析到 reply.
+//  如果 handler 没有回复消息则返回 ErrNoReply; reply == nil 时不解析回复的消息.
+//  默认的 InvalidRequestHandler 什么都不回复, 无效的请求也会返回 ErrNoReply, 测试的时候最好让它返回 4xx.
+func (cb *Callback) Do(handler http.Handler, msg, reply interface{}) (err error) {
+	r, err := cb.NewRequest(msg)
+	if err != nil {
+		return
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		return fmt.Errorf("http.Status: %d, body: %s", w.Code, w.Body.String())
+	}
+	body := bytes.TrimSpace(w.Body.Bytes())
+	if len(body) == 0 || string(body) == "success" {
+		return ErrNoReply
+	}
+	if reply == nil {
+		return
+	}
+	return cb.ParseResponse(body, reply)
+}
+
+func (cb *Callback) encrypt(rawMsg []byte) (base64EncryptedMsg string, err error) {
+	random := make([]byte, 16)
+	if _, err = rand.Read(random); err != nil {
+		return
+	}
+	encryptedMsg := util.AESEncryptMsg(random, rawMsg, cb.ReceiverId, cb.AESKey)
+	return base64.StdEncoding.EncodeToString(encryptedMsg), nil
+}
+
+func (cb *Callback) newRequest(method string, queryValues url.Values, body []byte) (r *http.Request, err error) {
+	rawURL := cb.URL
+	if rawURL == "" {
+		rawURL = "http://127.0.0.1/"
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+	query := u.Query()
+	for k, vs := range queryValues {
+		query[k] = vs
+	}
+	u.RawQuery = query.Encode()
+
+	r, err = http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	if method == "POST" {
+		r.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	}
+	return
+}
+
+func newNonce() (nonce string, err error) {
+	b := make([]byte, 8)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,104 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package corptest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/c77cc/wechat/corp"
+	"github.com/c77cc/wechat/corp/message/request"
+	"github.com/c77cc/wechat/corp/message/response"
+	"github.com/c77cc/wechat/corp/thirdparty"
+)
+
+func TestAgentCallback(t *testing.T) {
+	handler := corp.MessageHandlerFunc(func(w http.ResponseWriter, r *corp.Request) {
+		text := request.GetText(r.MixedMsg)
+		reply := response.NewText(text.FromUserName, text.ToUserName, text.CreateTime, "echo: "+text.Content)
+		corp.WriteResponse(w, r, reply)
+	})
+	aesKey := bytes.Repeat([]byte("k"), 32)
+	agentServer := corp.NewDefaultAgentServer("corpid", 1, "token", aesKey, handler)
+	frontend := corp.NewAgentServerFrontend(agentServer, invalidRequestHandler)
+
+	msg := &request.Text{
+		CommonMessageHeader: corp.CommonMessageHeader{
+			ToUserName:   "corpid",
+			FromUserName: "userid",
+			CreateTime:   time.Now().Unix(),
+			MsgType:      request.MsgTypeText,
+			AgentId:      1,
+		},
+		MsgId:   1,
+		Content: "hello",
+	}
+	cb := NewAgentCallback(agentServer)
+
+	var reply response.Text
+	if err := cb.Do(frontend, msg, &reply); err != nil {
+		t.Error(err)
+		return
+	}
+	if reply.ToUserName != "userid" || reply.Content != "echo: hello" {
+		t.Errorf("unexpected reply: %+v", reply)
+		return
+	}
+
+	// 错误的 Token 不能通过验证
+	cb2 := *cb
+	cb2.Token = "wrong"
+	if err := cb2.Do(frontend, msg, nil); err == nil || err == ErrNoReply {
+		t.Error("Do() with wrong token should fail")
+		return
+	}
+
+	r, err := cb.NewVerifyRequest("echostr")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	w := httptest.NewRecorder()
+	frontend.ServeHTTP(w, r)
+	if w.Body.String() != "echostr" {
+		t.Errorf("verify request got %q, want echostr", w.Body.String())
+	}
+}
+
+func TestSuiteCallback(t *testing.T) {
+	var suiteTicket string
+	handler := thirdparty.SuiteMessageHandlerFunc(func(w http.ResponseWriter, r *thirdparty.Request) {
+		if r.MixedMsg.InfoType == thirdparty.SuiteMsgTypeSuiteTicket {
+			suiteTicket = thirdparty.GetSuiteTicket(r.MixedMsg).SuiteTicket
+		}
+		w.Write([]byte("success"))
+	})
+	aesKey := bytes.Repeat([]byte("k"), 32)
+	suiteServer := thirdparty.NewDefaultSuiteServer("suiteid", "token", aesKey, handler)
+	frontend := thirdparty.NewSuiteServerFrontend(suiteServer, invalidRequestHandler)
+
+	msg := &thirdparty.SuiteTicket{
+		SuiteId:     "suiteid",
+		InfoType:    thirdparty.SuiteMsgTypeSuiteTicket,
+		Timestamp:   time.Now().Unix(),
+		SuiteTicket: "ticket",
+	}
+	if err := NewSuiteCallback(suiteServer).Do(frontend, msg, nil); err != ErrNoReply {
+		t.Errorf("Do() = %v, want ErrNoReply", err)
+		return
+	}
+	if suiteTicket != "ticket" {
+		t.Errorf("got suite_ticket %q, want ticket", suiteTicket)
+	}
+}
+
+// 无效的请求返回 400, 这样 Callback.Do 会返回错误.
+var invalidRequestHandler = corp.InvalidRequestHandlerFunc(func(w http.ResponseWriter, r *http.Request, err error) {
+	http.Error(w, err.Error(), http.StatusBadRequest)
+})
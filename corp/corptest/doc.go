@@ -0,0 +1,24 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+// 模拟微信企业号 api 的测试服务器, 用于离线的集成测试.
+//
+//  srv := corptest.NewServer("corpid", "corpsecret")
+//  defer srv.Close()
+//
+//  corp.DefaultEndpoint = srv.Endpoint() // DefaultAccessTokenServer 使用 corp.DefaultEndpoint
+//  tokenServer := corp.NewDefaultAccessTokenServer("corpid", "corpsecret", nil)
+//  corpClient := corp.NewCorpClient(tokenServer, nil)
+//
+//  // DefaultAccessTokenServer 获取 access_token 之后的 2 秒内不会重新获取, 所以要等 2 秒以上再调用 ExpireTokens,
+//  // 之后的请求返回 42001, CorpClient 会自动刷新 access_token 后重试.
+//  time.Sleep(2 * time.Second)
+//  srv.ExpireTokens()
+//
+// Callback 模拟企业号服务器推送给应用(或者套件)的回调消息:
+//
+//  cb := corptest.NewAgentCallback(agentServer)
+//  err := cb.Do(corp.NewAgentServerFrontend(agentServer, nil), &request.Text{...}, &reply)
+package corptest
@@ -0,0 +1,371 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package corptest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/c77cc/wechat/corp"
+	"github.com/c77cc/wechat/util"
+)
+
+const (
+	ErrCodeInvalidCredential  = 40001 // 不合法的 corpsecret
+	ErrCodeInvalidAccessToken = 40014 // 不合法的 access_token
+	ErrCodeInvalidCorpId      = 40013 // 不合法的 corpid
+	ErrCodeInvalidMediaId     = 40007 // 不合法的 media_id
+	ErrCodeAccessTokenMissing = 41001 // 缺少 access_token 参数
+	ErrCodeUserNotFound       = 60111 // userid 不存在
+)
+
+// 上传到测试服务器的多媒体文件
+type Media struct {
+	MediaId   string
+	MediaType string
+	FileName  string
+	Content   []byte
+	CreatedAt int64
+}
+
+// 发送到测试服务器的消息
+type Message struct {
+	Path string          // 请求的路径, 比如 /cgi-bin/message/send
+	Body json.RawMessage // 请求的 JSON
+}
+
+// 模拟微信企业号 api 的测试服务器.
+type Server struct {
+	*httptest.Server
+
+	corpId     string
+	corpSecret string
+
+	mutex          sync.Mutex
+	handlers       map[string]http.HandlerFunc // path => handler, 不包括获取 access_token 的 api
+	tokenExpiresIn int64
+	tokenSeq       int
+	tokens         map[string]int64 // access_token => 过期的时间戳
+	ticketSeq      int
+	injectedErrors []corp.Error
+	users          map[string]json.RawMessage
+	mediaSeq       int
+	media          map[string]*Media
+	messages       []Message
+}
+
+// 创建并启动一个新的测试服务器, 用完后需要调用 Close.
+func NewServer(corpId, corpSecret string) *Server {
+	srv := &Server{
+		corpId:         corpId,
+		corpSecret:     corpSecret,
+		tokenExpiresIn: 7200,
+		tokens:         make(map[string]int64),
+		handlers:       make(map[string]http.HandlerFunc),
+		users:          make(map[string]json.RawMessage),
+		media:          make(map[string]*Media),
+	}
+
+	srv.HandleFunc("/cgi-bin/getcallbackip", srv.serveGetCallbackIP)
+	srv.HandleFunc("/cgi-bin/get_jsapi_ticket", srv.serveGetTicket)
+	srv.HandleFunc("/cgi-bin/user/get", srv.serveUserGet)
+	srv.HandleFunc("/cgi-bin/media/upload", srv.serveMediaUpload)
+	srv.HandleFunc("/cgi-bin/media/get", srv.serveMediaGet)
+	srv.HandleFunc("/cgi-bin/message/send", srv.serveMessage)
+
+	srv.Server = httptest.NewServer(http.HandlerFunc(srv.dispatch))
+	return srv
+}
+
+// 返回指向这个测试服务器的 Endpoint.
+func (srv *Server) Endpoint() *util.Endpoint {
+	return &util.Endpoint{
+		CorpAPIBaseURL: srv.URL,
+	}
+}
+
+// 注册一个需要 access_token 的 api, 可以用来模拟本包没有实现的 api 或者覆盖默认的实现.
+//  在调用 handler 之前, 测试服务器已经校验了 access_token 并处理了 InjectError 注入的错误.
+//  path 为 api 的路径, 比如 "/cgi-bin/user/get", 重复注册会覆盖之前的 handler.
+func (srv *Server) HandleFunc(path string, handler http.HandlerFunc) {
+	srv.mutex.Lock()
+	srv.handlers[path] = handler
+	srv.mutex.Unlock()
+}
+
+func (srv *Server) dispatch(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/cgi-bin/gettoken" {
+		srv.serveToken(w, r)
+		return
+	}
+
+	srv.mutex.Lock()
+	handler := srv.handlers[r.URL.Path]
+	srv.mutex.Unlock()
+
+	if handler == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if !srv.checkRequest(w, r) {
+		return
+	}
+	handler(w, r)
+}
+
+// 设置新签发的 access_token 的有效时间, 单位为秒, 默认为 7200.
+func (srv *Server) SetTokenExpiresIn(expiresIn int64) {
+	srv.mutex.Lock()
+	srv.tokenExpiresIn = expiresIn
+	srv.mutex.Unlock()
+}
+
+// 让所有已经签发的 access_token 过期, 之后使用这些 access_token 的请求返回 42001.
+//  DefaultAccessTokenServer 在获取 access_token 之后的 2 秒内刷新会返回同一个 access_token,
+//  所以在这 2 秒内调用 ExpireTokens 的话, 客户端刷新后重试仍然返回 42001.
+func (srv *Server) ExpireTokens() {
+	srv.mutex.Lock()
+	for token := range srv.tokens {
+		srv.tokens[token] = 0
+	}
+	srv.mutex.Unlock()
+}
+
+// 作废所有已经签发的 access_token, 之后使用这些 access_token 的请求返回 40014.
+func (srv *Server) RevokeTokens() {
+	srv.mutex.Lock()
+	srv.tokens = make(map[string]int64)
+	srv.mutex.Unlock()
+}
+
+// 返回测试服务器一共签发了多少个 access_token.
+func (srv *Server) TokenCount() int {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+	return srv.tokenSeq
+}
+
+// 注入一个错误, 下一个需要 access_token 的请求(校验 access_token 之后)直接返回这个错误.
+//  多次调用按照调用的顺序依次返回.
+func (srv *Server) InjectError(errCode int, errMsg string) {
+	srv.mutex.Lock()
+	srv.injectedErrors = append(srv.injectedErrors, corp.Error{ErrCode: errCode, ErrMsg: errMsg})
+	srv.mutex.Unlock()
+}
+
+// 添加一个成员, info 会被 marshal 为 JSON 作为 /cgi-bin/user/get 的返回,
+// 所以 info 一般是 addresslist.UserInfo 或者 *addresslist.UserInfo.
+func (srv *Server) AddUser(userId string, info interface{}) (err error) {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return
+	}
+
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	srv.users[userId] = data
+	return
+}
+
+// 获取上传到测试服务器的多媒体文件.
+func (srv *Server) Media(mediaId string) (media *Media, ok bool) {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	media, ok = srv.media[mediaId]
+	return
+}
+
+// 返回发送到测试服务器的所有消息.
+func (srv *Server) Messages() []Message {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	messages := make([]Message, len(srv.messages))
+	copy(messages, srv.messages)
+	return messages
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, errCode int, errMsg string) {
+	writeJSON(w, &corp.Error{ErrCode: errCode, ErrMsg: errMsg})
+}
+
+// 校验 access_token 以及处理注入的错误, 返回 false 表示已经写入了错误.
+func (srv *Server) checkRequest(w http.ResponseWriter, r *http.Request) bool {
+	token := r.URL.Query().Get("access_token")
+	if token == "" {
+		writeError(w, ErrCodeAccessTokenMissing, "access_token missing")
+		return false
+	}
+
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	expiresAt, ok := srv.tokens[token]
+	if !ok {
+		writeError(w, ErrCodeInvalidAccessToken, "invalid access_token")
+		return false
+	}
+	if time.Now().Unix() >= expiresAt {
+		writeError(w, corp.ErrCodeAccessTokenExpired, "access_token expired")
+		return false
+	}
+	if len(srv.injectedErrors) > 0 {
+		e := srv.injectedErrors[0]
+		srv.injectedErrors = srv.injectedErrors[1:]
+		writeError(w, e.ErrCode, e.ErrMsg)
+		return false
+	}
+	return true
+}
+
+func (srv *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	queryValues := r.URL.Query()
+	if queryValues.Get("corpid") != srv.corpId {
+		writeError(w, ErrCodeInvalidCorpId, "invalid corpid")
+		return
+	}
+	if queryValues.Get("corpsecret") != srv.corpSecret {
+		writeError(w, ErrCodeInvalidCredential, "invalid credential")
+		return
+	}
+
+	srv.mutex.Lock()
+	srv.tokenSeq++
+	token := "ACCESS_TOKEN_" + strconv.Itoa(srv.tokenSeq)
+	expiresIn := srv.tokenExpiresIn
+	srv.tokens[token] = time.Now().Unix() + expiresIn
+	srv.mutex.Unlock()
+
+	writeJSON(w, map[string]interface{}{
+		"access_token": token,
+		"expires_in":   expiresIn,
+	})
+}
+
+func (srv *Server) serveGetCallbackIP(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"ip_list": []string{"127.0.0.1"},
+	})
+}
+
+func (srv *Server) serveGetTicket(w http.ResponseWriter, r *http.Request) {
+	srv.mutex.Lock()
+	srv.ticketSeq++
+	ticket := "JSAPI_TICKET_" + strconv.Itoa(srv.ticketSeq)
+	srv.mutex.Unlock()
+
+	writeJSON(w, map[string]interface{}{
+		"errcode":    corp.ErrCodeOK,
+		"errmsg":     "ok",
+		"ticket":     ticket,
+		"expires_in": 7200,
+	})
+}
+
+func (srv *Server) serveUserGet(w http.ResponseWriter, r *http.Request) {
+	userId := r.URL.Query().Get("userid")
+
+	srv.mutex.Lock()
+	info, ok := srv.users[userId]
+	srv.mutex.Unlock()
+
+	if !ok {
+		writeError(w, ErrCodeUserNotFound, "userid not found")
+		return
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(info, &result); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result["errcode"] = corp.ErrCodeOK
+	result["errmsg"] = "ok"
+	writeJSON(w, result)
+}
+
+func (srv *Server) serveMediaUpload(w http.ResponseWriter, r *http.Request) {
+	mediaType := r.URL.Query().Get("type")
+
+	file, header, err := r.FormFile("media")
+	if err != nil {
+		writeError(w, 41005, "media data missing")
+		return
+	}
+	defer file.Close()
+
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	srv.mutex.Lock()
+	srv.mediaSeq++
+	media := &Media{
+		MediaId:   "MEDIA_ID_" + strconv.Itoa(srv.mediaSeq),
+		MediaType: mediaType,
+		FileName:  header.Filename,
+		Content:   content,
+		CreatedAt: time.Now().Unix(),
+	}
+	srv.media[media.MediaId] = media
+	srv.mutex.Unlock()
+
+	writeJSON(w, map[string]interface{}{
+		"type":       media.MediaType,
+		"media_id":   media.MediaId,
+		"created_at": media.CreatedAt,
+	})
+}
+
+func (srv *Server) serveMediaGet(w http.ResponseWriter, r *http.Request) {
+	media, ok := srv.Media(r.URL.Query().Get("media_id"))
+	if !ok {
+		writeError(w, ErrCodeInvalidMediaId, "invalid media_id")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+media.FileName+`"`)
+	w.Write(media.Content)
+}
+
+func (srv *Server) serveMessage(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !json.Valid(body) {
+		writeError(w, 47001, "data format error")
+		return
+	}
+
+	srv.mutex.Lock()
+	srv.messages = append(srv.messages, Message{Path: r.URL.Path, Body: body})
+	srv.mutex.Unlock()
+
+	writeJSON(w, map[string]interface{}{
+		"errcode":      corp.ErrCodeOK,
+		"errmsg":       "ok",
+		"invaliduser":  "",
+		"invalidparty": "",
+		"invalidtag":   "",
+	})
+}
@@ -22,3 +22,17 @@ type Error struct {
 func (e *Error) Error() string {
 	return fmt.Sprintf("errcode: %d, errmsg: %s", e.ErrCode, e.ErrMsg)
 }
+
+// 支持 errors.Is, target 可以是返回码的分类(比如 util.ErrRateLimited), 也可以是 *Error(只比较 ErrCode).
+func (e *Error) Is(target error) bool {
+	if t, ok := target.(*Error); ok {
+		return t.ErrCode == e.ErrCode
+	}
+	category := errCodeTable[e.ErrCode].category
+	return category != nil && category == target
+}
+
+// 返回码的说明, 见 ErrCodeText.
+func (e *Error) Description() string {
+	return ErrCodeText(e.ErrCode)
+}
@@ -0,0 +1,207 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package corp
+
+import (
+	"github.com/c77cc/wechat/util"
+)
+
+type errCodeInfo struct {
+	text     string // 返回码的说明
+	category error  // 返回码的分类, 见 util.ErrInvalidCredential 等; nil 表示没有分类
+}
+
+// 企业号全局返回码, 见 http://qydev.weixin.qq.com/wiki/index.php?title=全局返回码说明
+var errCodeTable = map[int]errCodeInfo{
+	-1:    {"系统繁忙", nil},
+	0:     {"请求成功", nil},
+	40001: {"获取 access_token 时 Secret 错误, 或者 access_token 无效", util.ErrInvalidCredential},
+	40002: {"不合法的凭证类型", util.ErrInvalidCredential},
+	40003: {"不合法的 UserID", nil},
+	40004: {"不合法的媒体文件类型", nil},
+	40005: {"不合法的文件类型", nil},
+	40006: {"不合法的文件大小", nil},
+	40007: {"不合法的媒体文件 id", nil},
+	40008: {"不合法的消息类型", nil},
+	40013: {"不合法的 corpid", util.ErrInvalidCredential},
+	40014: {"不合法的 access_token", util.ErrInvalidCredential},
+	40015: {"不合法的菜单类型", nil},
+	40016: {"不合法的按钮个数", nil},
+	40017: {"不合法的按钮类型", nil},
+	40018: {"不合法的按钮名字长度", nil},
+	40019: {"不合法的按钮 KEY 长度", nil},
+	40020: {"不合法的按钮 URL 长度", nil},
+	40021: {"不合法的菜单版本号", nil},
+	40022: {"不合法的子菜单级数", nil},
+	40023: {"不合法的子菜单按钮个数", nil},
+	40024: {"不合法的子菜单按钮类型", nil},
+	40025: {"不合法的子菜单按钮名字长度", nil},
+	40026: {"不合法的子菜单按钮 KEY 长度", nil},
+	40027: {"不合法的子菜单按钮 URL 长度", nil},
+	40028: {"不合法的自定义菜单使用员工", nil},
+	40029: {"不合法的 oauth_code", nil},
+	40031: {"不合法的 UserID 列表", nil},
+	40032: {"不合法的 UserID 列表长度", nil},
+	40033: {"不合法的请求字符, 不能包含 \\uxxxx 格式的字符", nil},
+	40035: {"不合法的参数", nil},
+	40038: {"不合法的请求格式", nil},
+	40039: {"不合法的 URL 长度", nil},
+	40040: {"不合法的插件 token", nil},
+	40041: {"不合法的插件 id", nil},
+	40042: {"不合法的插件会话", nil},
+	40048: {"url 中包含不合法 domain", nil},
+	40054: {"不合法的子菜单 url 域名", nil},
+	40055: {"不合法的按钮 url 域名", nil},
+	40056: {"不合法的 agentid", nil},
+	40057: {"不合法的 callbackurl", nil},
+	40058: {"不合法的红包参数", nil},
+	40059: {"不合法的上报地理位置标志位", nil},
+	40060: {"设置上报地理位置标志位时没有设置 callbackurl", nil},
+	40061: {"设置应用头像失败", nil},
+	40062: {"不合法的应用模式", nil},
+	40063: {"红包参数为空", nil},
+	40064: {"管理组名字已存在", nil},
+	40065: {"不合法的管理组名字长度", nil},
+	40066: {"不合法的部门列表", nil},
+	40067: {"标题长度不合法", nil},
+	40068: {"不合法的标签 ID", nil},
+	40069: {"不合法的标签 ID 列表", nil},
+	40070: {"列表中所有标签(用户)ID 都不合法", nil},
+	40071: {"不合法的标签名字, 标签名字已经存在", nil},
+	40072: {"不合法的标签名字长度", nil},
+	40073: {"不合法的 openid", nil},
+	40074: {"news 消息不支持指定为高保密消息", nil},
+	40077: {"不合法的预授权码", nil},
+	40078: {"不合法的临时授权码", nil},
+	40079: {"不合法的授权信息", nil},
+	40080: {"不合法的 suitesecret", util.ErrInvalidCredential},
+	40082: {"不合法的 suitetoken", util.ErrInvalidCredential},
+	40083: {"不合法的 suiteid", util.ErrInvalidCredential},
+	40084: {"不合法的永久授权码", nil},
+	40085: {"不合法的 suiteticket", nil},
+	40086: {"不合法的第三方应用 appid", nil},
+	41001: {"缺少 access_token 参数", util.ErrInvalidCredential},
+	41002: {"缺少 corpid 参数", util.ErrInvalidCredential},
+	41003: {"缺少 refresh_token 参数", nil},
+	41004: {"缺少 secret 参数", util.ErrInvalidCredential},
+	41005: {"缺少多媒体文件数据", nil},
+	41006: {"缺少 media_id 参数", nil},
+	41007: {"缺少子菜单数据", nil},
+	41008: {"缺少 oauth code", nil},
+	41009: {"缺少 UserID", nil},
+	41010: {"缺少 url", nil},
+	41011: {"缺少 agentid", nil},
+	41012: {"缺少应用头像 mediaid", nil},
+	41013: {"缺少应用名字", nil},
+	41014: {"缺少应用描述", nil},
+	41015: {"缺少 Content", nil},
+	41016: {"缺少标题", nil},
+	41017: {"缺少标签 ID", nil},
+	41018: {"缺少标签名字", nil},
+	41021: {"缺少 suiteid", nil},
+	41022: {"缺少 suitetoken", nil},
+	41023: {"缺少 suiteticket", nil},
+	41024: {"缺少 suitesecret", nil},
+	41025: {"缺少永久授权码", nil},
+	42001: {"access_token 超时", util.ErrInvalidCredential},
+	42002: {"refresh_token 超时", nil},
+	42003: {"oauth_code 超时", nil},
+	42004: {"插件 token 超时", nil},
+	42007: {"预授权码失效", nil},
+	42008: {"临时授权码失效", nil},
+	42009: {"suitetoken 失效", util.ErrInvalidCredential},
+	43001: {"需要 GET 请求", nil},
+	43002: {"需要 POST 请求", nil},
+	43003: {"需要 HTTPS", nil},
+	43004: {"需要成员已关注", util.ErrNotSubscribed},
+	43005: {"需要好友关系", nil},
+	43006: {"需要订阅", nil},
+	43007: {"需要授权", nil},
+	43008: {"需要支付授权", nil},
+	43010: {"需要处于回调模式", nil},
+	43011: {"需要企业授权", nil},
+	44001: {"多媒体文件为空", nil},
+	44002: {"POST 的数据包为空", nil},
+	44003: {"图文消息内容为空", nil},
+	44004: {"文本消息内容为空", nil},
+	45001: {"多媒体文件大小超过限制", nil},
+	45002: {"消息内容大小超过限制", nil},
+	45003: {"标题大小超过限制", nil},
+	45004: {"描述大小超过限制", nil},
+	45005: {"链接长度超过限制", nil},
+	45006: {"图片链接长度超过限制", nil},
+	45007: {"语音播放时间超过限制", nil},
+	45008: {"图文消息的文章数量不能超过 10 条", nil},
+	45009: {"接口调用超过限制", util.ErrRateLimited},
+	45010: {"创建菜单个数超过限制", nil},
+	45015: {"回复时间超过限制", util.ErrResponseTimeout},
+	45016: {"系统分组, 不允许修改", nil},
+	45017: {"分组名字过长", nil},
+	45018: {"分组数量超过上限", nil},
+	45024: {"账号数量超过上限", nil},
+	45033: {"接口并发调用超过限制", util.ErrRateLimited},
+	46001: {"不存在媒体数据", nil},
+	46002: {"不存在的菜单版本", nil},
+	46003: {"不存在的菜单数据", nil},
+	46004: {"不存在的员工", nil},
+	47001: {"解析 JSON/XML 内容错误", nil},
+	48002: {"Api 禁用", nil},
+	48003: {"suitetoken 无效", nil},
+	48004: {"授权关系无效", nil},
+	50001: {"redirect_uri 未授权", nil},
+	50002: {"员工不在权限范围", nil},
+	50003: {"应用已停用", nil},
+	50004: {"员工状态不正确(未关注状态)", nil},
+	50005: {"企业已禁用", nil},
+	60001: {"部门长度不符合限制", nil},
+	60002: {"部门层级深度超过限制", nil},
+	60003: {"部门不存在", nil},
+	60004: {"父亲部门不存在", nil},
+	60005: {"不允许删除有成员的部门", nil},
+	60006: {"不允许删除有子部门的部门", nil},
+	60007: {"不允许删除根部门", nil},
+	60008: {"部门名称已存在", nil},
+	60009: {"部门名称含有非法字符", nil},
+	60010: {"部门存在循环关系", nil},
+	60011: {"管理员权限不足, (user/department/agent)无权限", nil},
+	60012: {"不允许删除默认应用", nil},
+	60013: {"不允许关闭应用", nil},
+	60014: {"不允许开启应用", nil},
+	60015: {"不允许修改默认应用可见范围", nil},
+	60016: {"不允许删除存在成员的标签", nil},
+	60017: {"不允许设置企业", nil},
+	60102: {"UserID 已存在", nil},
+	60103: {"手机号码不合法", nil},
+	60104: {"手机号码已存在", nil},
+	60105: {"邮箱不合法", nil},
+	60106: {"邮箱已存在", nil},
+	60107: {"微信号不合法", nil},
+	60108: {"微信号已存在", nil},
+	60109: {"QQ 号已存在", nil},
+	60110: {"部门个数超出限制", nil},
+	60111: {"UserID 不存在", nil},
+	60112: {"成员姓名不合法", nil},
+	60113: {"身份认证信息(微信号/手机/邮箱)不能同时为空", nil},
+	60114: {"性别不合法", nil},
+	60115: {"已关注成员微信不能修改", nil},
+	60116: {"扩展属性已存在", nil},
+	60118: {"成员无有效邀请字段, 详情参考(邀请成员关注)的接口说明", nil},
+	60119: {"成员已关注", nil},
+	60120: {"成员已禁用", nil},
+	60121: {"找不到该成员", nil},
+	60122: {"邮箱已被外部管理员使用", nil},
+	60123: {"无效的部门 id", nil},
+	60124: {"无效的父部门 id", nil},
+	60125: {"非法部门名字, 长度超过限制, 重名等", nil},
+	60126: {"创建部门失败", nil},
+	60127: {"缺少部门 id", nil},
+	60128: {"字段不合法, 可能存在主键冲突或者格式错误", nil},
+}
+
+// 返回全局返回码 code 的说明, 未知的返回码返回 "".
+func ErrCodeText(code int) string {
+	return errCodeTable[code].text
+}
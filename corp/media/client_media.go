@@ -8,7 +8,6 @@ package media
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"mime"
 	"net/http"
@@ -16,6 +15,7 @@ import (
 	"os"
 
 	"github.com/c77cc/wechat/corp"
+	"github.com/c77cc/wechat/util"
 )
 
 // 下载多媒体到文件.
@@ -51,17 +51,17 @@ func (clt Client) downloadMediaToWriter(mediaId string, writer io.Writer) (err e
 
 	hasRetried := false
 RETRY:
-	finalURL := "https://qyapi.weixin.qq.com/cgi-bin/media/get?media_id=" + url.QueryEscape(mediaId) +
+	finalURL := clt.EndpointURL("https://qyapi.weixin.qq.com/cgi-bin/media/get?media_id=") + url.QueryEscape(mediaId) +
 		"&access_token=" + url.QueryEscape(token)
 
-	httpResp, err := clt.HttpClient.Get(finalURL)
+	httpResp, err := util.HttpGetContext(clt.Context(), clt.HttpClient, finalURL)
 	if err != nil {
 		return
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("http.Status: %s", httpResp.Status)
+		return &util.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 	}
 
 	ContentType, _, _ := mime.ParseMediaType(httpResp.Header.Get("Content-Type"))
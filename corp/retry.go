@@ -0,0 +1,22 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package corp
+
+import (
+	"github.com/c77cc/wechat/util"
+)
+
+// 默认的重试策略, 所有没有指定 RetryPolicy 的 CorpClient 都使用这个策略; 默认为 nil, 不重试.
+//  access_token 失效的重试不受 RetryPolicy 控制, 总是会刷新 access_token 后重试一次.
+//  沒有加锁, 请确保在初始化阶段修改!
+var DefaultRetryPolicy *util.RetryPolicy
+
+func (clt *CorpClient) retryPolicy() *util.RetryPolicy {
+	if clt.RetryPolicy != nil {
+		return clt.RetryPolicy
+	}
+	return DefaultRetryPolicy
+}
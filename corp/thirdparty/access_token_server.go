@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/c77cc/wechat/corp"
+	"github.com/c77cc/wechat/util"
 )
 
 var _ corp.AccessTokenServer = (*DefaultAccessTokenServer)(nil)
@@ -27,7 +28,7 @@ type DefaultAccessTokenServer struct {
 	authCorpId    string
 	permanentCode string
 
-	resetTickerChan chan time.Duration // 用于重置 tokenDaemon 里的 ticker
+	refresher *util.RefreshScheduler // 定时刷新 access_token
 
 	tokenGet struct {
 		sync.Mutex
@@ -37,7 +38,8 @@ type DefaultAccessTokenServer struct {
 
 	tokenCache struct {
 		sync.RWMutex
-		Token string
+		Token     string
+		ExpiresAt time.Time
 	}
 }
 
@@ -59,21 +61,22 @@ func NewDefaultAccessTokenServer(suiteId string, suiteAccessTokenServer SuiteAcc
 			SuiteAccessTokenServer: suiteAccessTokenServer,
 			HttpClient:             httpClient,
 		},
-		authCorpId:      authCorpId,
-		permanentCode:   permanentCode,
-		resetTickerChan: make(chan time.Duration),
+		authCorpId:    authCorpId,
+		permanentCode: permanentCode,
 	}
 
-	go srv.tokenDaemon(time.Hour * 24) // 启动 tokenDaemon
+	srv.refresher = util.NewRefreshScheduler("corp/thirdparty/access_token/"+suiteId+"/"+authCorpId, srv.refresh)
 	return
 }
 
 func (srv *DefaultAccessTokenServer) Token() (token string, err error) {
 	srv.tokenCache.RLock()
 	token = srv.tokenCache.Token
+	expiresAt := srv.tokenCache.ExpiresAt
 	srv.tokenCache.RUnlock()
 
-	if token != "" {
+	// 刷新失败的时候继续使用上一次获取的 access_token, 直到过期
+	if token != "" && time.Now().Before(expiresAt) {
 		return
 	}
 	return srv.TokenRefresh()
@@ -81,41 +84,32 @@ func (srv *DefaultAccessTokenServer) Token() (token string, err error) {
 
 func (srv *DefaultAccessTokenServer) TokenRefresh() (token string, err error) {
 	AccessTokenInfo, cached, err := srv.getToken()
+	if !cached {
+		srv.refresher.Refreshed(time.Duration(AccessTokenInfo.ExpiresIn)*time.Second, err)
+	}
 	if err != nil {
 		return
 	}
-	if !cached {
-		srv.resetTickerChan <- time.Duration(AccessTokenInfo.ExpiresIn) * time.Second
-	}
 	token = AccessTokenInfo.Token
 	return
 }
 
-func (srv *DefaultAccessTokenServer) tokenDaemon(tickDuration time.Duration) {
-NEW_TICK_DURATION:
-	ticker := time.NewTicker(tickDuration)
-
-	for {
-		select {
-		case tickDuration = <-srv.resetTickerChan:
-			ticker.Stop()
-			goto NEW_TICK_DURATION
-
-		case <-ticker.C:
-			AccessTokenInfo, cached, err := srv.getToken()
-			if err != nil {
-				break
-			}
-			if !cached {
-				newTickDuration := time.Duration(AccessTokenInfo.ExpiresIn) * time.Second
-				if tickDuration != newTickDuration {
-					tickDuration = newTickDuration
-					ticker.Stop()
-					goto NEW_TICK_DURATION
-				}
-			}
-		}
-	}
+// 停止定时刷新 access_token 的后台 goroutine, 等待其退出后返回, 可以重复调用.
+//  Close 之后 Token 和 TokenRefresh 仍然可用, 只是不再定时刷新.
+func (srv *DefaultAccessTokenServer) Close() error {
+	srv.refresher.Close()
+	return nil
+}
+
+// 设置定时刷新 access_token 的事件回调, hook == nil 的时候使用 util.DefaultRefreshHook.
+func (srv *DefaultAccessTokenServer) SetRefreshHook(hook util.RefreshHookFunc) {
+	srv.refresher.SetHook(hook)
+}
+
+// 由 refresher 定时调用.
+func (srv *DefaultAccessTokenServer) refresh() (expiresIn time.Duration, err error) {
+	info, _, err := srv.getToken()
+	return time.Duration(info.ExpiresIn) * time.Second, err
 }
 
 type AccessTokenInfo struct {
@@ -159,17 +153,10 @@ func (srv *DefaultAccessTokenServer) getToken() (token AccessTokenInfo, cached b
 
 	incompleteURL := "https://qyapi.weixin.qq.com/cgi-bin/service/get_corp_token?suite_access_token="
 	if err = srv.suiteClient.PostJSON(incompleteURL, &request, &result); err != nil {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
 		return
 	}
 
 	if result.ErrCode != corp.ErrCodeOK {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
 		err = &result.Error
 		return
 	}
@@ -177,10 +164,6 @@ func (srv *DefaultAccessTokenServer) getToken() (token AccessTokenInfo, cached b
 	// 由于网络的延时, suite_access_token 过期时间留了一个缓冲区
 	switch {
 	case result.ExpiresIn > 31556952: // 60*60*24*365.2425
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
 		err = errors.New("expires_in too large: " + strconv.FormatInt(result.ExpiresIn, 10))
 		return
 	case result.ExpiresIn > 60*60:
@@ -192,10 +175,6 @@ func (srv *DefaultAccessTokenServer) getToken() (token AccessTokenInfo, cached b
 	case result.ExpiresIn > 60:
 		result.ExpiresIn -= 10
 	default:
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
 		err = errors.New("expires_in too small: " + strconv.FormatInt(result.ExpiresIn, 10))
 		return
 	}
@@ -207,6 +186,7 @@ func (srv *DefaultAccessTokenServer) getToken() (token AccessTokenInfo, cached b
 	// 更新缓存
 	srv.tokenCache.Lock()
 	srv.tokenCache.Token = result.AccessTokenInfo.Token
+	srv.tokenCache.ExpiresAt = time.Now().Add(time.Duration(result.AccessTokenInfo.ExpiresIn) * time.Second)
 	srv.tokenCache.Unlock()
 
 	token = result.AccessTokenInfo
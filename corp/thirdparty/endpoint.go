@@ -0,0 +1,33 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package thirdparty
+
+import (
+	"github.com/c77cc/wechat/corp"
+	"github.com/c77cc/wechat/util"
+)
+
+// 把官方的 api 地址 rawURL 转换为 clt 配置的地址, 见 util.Endpoint.
+//  如果 clt.Endpoint == nil 则使用 corp.DefaultEndpoint.
+func (clt *SuiteClient) EndpointURL(rawURL string) string {
+	if clt.Endpoint != nil {
+		return clt.Endpoint.URL(rawURL)
+	}
+	return corp.DefaultEndpoint.URL(rawURL)
+}
+
+// 设置 srv 获取 suite_access_token 的 api 地址配置, 如果 endpoint == nil 则使用 corp.DefaultEndpoint.
+//  沒有加锁, 请确保在初始化阶段调用!
+func (srv *DefaultSuiteAccessTokenServer) SetEndpoint(endpoint *util.Endpoint) {
+	srv.endpoint = endpoint
+}
+
+func (srv *DefaultSuiteAccessTokenServer) endpointURL(rawURL string) string {
+	if srv.endpoint != nil {
+		return srv.endpoint.URL(rawURL)
+	}
+	return corp.DefaultEndpoint.URL(rawURL)
+}
@@ -81,7 +81,13 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, queryValues url.Values,
 		// 验证签名
 		msgSignature2 := util.MsgSign(suiteToken, timestampStr, nonce, requestHttpBody.EncryptedMsg)
 		if subtle.ConstantTimeCompare([]byte(msgSignature1), []byte(msgSignature2)) != 1 {
-			err = fmt.Errorf("check signature failed, input: %s, local: %s", msgSignature1, msgSignature2)
+			err = &util.SignatureError{Name: "msg_signature", Input: msgSignature1, Local: msgSignature2}
+			invalidRequestHandler.ServeInvalidRequest(w, r, err)
+			return
+		}
+
+		// 重放检查, 和企业号应用共用 corp.DefaultReplayGuard
+		if err = corp.DefaultReplayGuard.Check(wantSuiteId, timestamp, nonce); err != nil {
 			invalidRequestHandler.ServeInvalidRequest(w, r, err)
 			return
 		}
@@ -161,7 +167,7 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, queryValues url.Values,
 
 		msgSignature2 := util.MsgSign(suiteServer.SuiteToken(), timestamp, nonce, encryptedMsg)
 		if subtle.ConstantTimeCompare([]byte(msgSignature1), []byte(msgSignature2)) != 1 {
-			err = fmt.Errorf("check signature failed, input: %s, local: %s", msgSignature1, msgSignature2)
+			err = &util.SignatureError{Name: "msg_signature", Input: msgSignature1, Local: msgSignature2}
 			invalidRequestHandler.ServeInvalidRequest(w, r, err)
 			return
 		}
@@ -11,7 +11,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
@@ -19,6 +18,7 @@ import (
 	"time"
 
 	"github.com/c77cc/wechat/corp"
+	"github.com/c77cc/wechat/util"
 )
 
 // suite_access_token 中控服务器接口.
@@ -48,8 +48,9 @@ type DefaultSuiteAccessTokenServer struct {
 	suiteSecret       string
 	suiteTicketGetter SuiteTicketGetter
 	httpClient        *http.Client
+	endpoint          *util.Endpoint // 见 SetEndpoint
 
-	resetTickerChan chan time.Duration // 用于重置 tokenDaemon 里的 ticker
+	refresher *util.RefreshScheduler // 定时刷新 suite_access_token
 
 	tokenGet struct {
 		sync.Mutex
@@ -59,7 +60,8 @@ type DefaultSuiteAccessTokenServer struct {
 
 	tokenCache struct {
 		sync.RWMutex
-		Token string
+		Token     string
+		ExpiresAt time.Time
 	}
 }
 
@@ -86,19 +88,20 @@ func NewDefaultSuiteAccessTokenServer(suiteId, suiteSecret string, suiteTicketGe
 		suiteSecret:       suiteSecret,
 		suiteTicketGetter: suiteTicketGetter,
 		httpClient:        httpClient,
-		resetTickerChan:   make(chan time.Duration),
 	}
 
-	go srv.tokenDaemon(time.Hour * 24) // 启动 tokenDaemon
+	srv.refresher = util.NewRefreshScheduler("corp/suite_access_token/"+suiteId, srv.refresh)
 	return
 }
 
 func (srv *DefaultSuiteAccessTokenServer) Token() (token string, err error) {
 	srv.tokenCache.RLock()
 	token = srv.tokenCache.Token
+	expiresAt := srv.tokenCache.ExpiresAt
 	srv.tokenCache.RUnlock()
 
-	if token != "" {
+	// 刷新失败的时候继续使用上一次获取的 suite_access_token, 直到过期
+	if token != "" && time.Now().Before(expiresAt) {
 		return
 	}
 	return srv.TokenRefresh()
@@ -106,41 +109,32 @@ func (srv *DefaultSuiteAccessTokenServer) Token() (token string, err error) {
 
 func (srv *DefaultSuiteAccessTokenServer) TokenRefresh() (token string, err error) {
 	suiteAccessTokenInfo, cached, err := srv.getToken()
+	if !cached {
+		srv.refresher.Refreshed(time.Duration(suiteAccessTokenInfo.ExpiresIn)*time.Second, err)
+	}
 	if err != nil {
 		return
 	}
-	if !cached {
-		srv.resetTickerChan <- time.Duration(suiteAccessTokenInfo.ExpiresIn) * time.Second
-	}
 	token = suiteAccessTokenInfo.Token
 	return
 }
 
-func (srv *DefaultSuiteAccessTokenServer) tokenDaemon(tickDuration time.Duration) {
-NEW_TICK_DURATION:
-	ticker := time.NewTicker(tickDuration)
-
-	for {
-		select {
-		case tickDuration = <-srv.resetTickerChan:
-			ticker.Stop()
-			goto NEW_TICK_DURATION
-
-		case <-ticker.C:
-			suiteAccessTokenInfo, cached, err := srv.getToken()
-			if err != nil {
-				break
-			}
-			if !cached {
-				newTickDuration := time.Duration(suiteAccessTokenInfo.ExpiresIn) * time.Second
-				if tickDuration != newTickDuration {
-					tickDuration = newTickDuration
-					ticker.Stop()
-					goto NEW_TICK_DURATION
-				}
-			}
-		}
-	}
+// 停止定时刷新 suite_access_token 的后台 goroutine, 等待其退出后返回, 可以重复调用.
+//  Close 之后 Token 和 TokenRefresh 仍然可用, 只是不再定时刷新.
+func (srv *DefaultSuiteAccessTokenServer) Close() error {
+	srv.refresher.Close()
+	return nil
+}
+
+// 设置定时刷新 suite_access_token 的事件回调, hook == nil 的时候使用 util.DefaultRefreshHook.
+func (srv *DefaultSuiteAccessTokenServer) SetRefreshHook(hook util.RefreshHookFunc) {
+	srv.refresher.SetHook(hook)
+}
+
+// 由 refresher 定时调用.
+func (srv *DefaultSuiteAccessTokenServer) refresh() (expiresIn time.Duration, err error) {
+	info, _, err := srv.getToken()
+	return time.Duration(info.ExpiresIn) * time.Second, err
 }
 
 type suiteAccessTokenInfo struct {
@@ -169,9 +163,6 @@ func (srv *DefaultSuiteAccessTokenServer) getToken() (token suiteAccessTokenInfo
 
 	suiteTicket, err := srv.suiteTicketGetter.GetSuiteTicket(srv.suiteId)
 	if err != nil {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
 		return
 	}
 
@@ -190,33 +181,23 @@ func (srv *DefaultSuiteAccessTokenServer) getToken() (token suiteAccessTokenInfo
 	defer textBufferPool.Put(requestBuf)
 
 	if err = json.NewEncoder(requestBuf).Encode(&request); err != nil {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
 		return
 	}
 	requestBytes := requestBuf.Bytes()
 
-	url := "https://qyapi.weixin.qq.com/cgi-bin/service/get_suite_token"
+	url := srv.endpointURL("https://qyapi.weixin.qq.com/cgi-bin/service/get_suite_token")
 
 	corp.LogInfoln("[WECHAT_DEBUG] request url:", url)
 	corp.LogInfoln("[WECHAT_DEBUG] request json:", string(requestBytes))
 
 	httpResp, err := srv.httpClient.Post(url, "application/json; charset=utf-8", bytes.NewReader(requestBytes))
 	if err != nil {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
 		return
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
-		err = fmt.Errorf("http.Status: %s", httpResp.Status)
+		err = &util.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 		return
 	}
 
@@ -227,26 +208,16 @@ func (srv *DefaultSuiteAccessTokenServer) getToken() (token suiteAccessTokenInfo
 
 	respBody, err := ioutil.ReadAll(httpResp.Body)
 	if err != nil {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
 		return
 	}
 
 	corp.LogInfoln("[WECHAT_DEBUG] response json:", string(respBody))
 
 	if err = json.Unmarshal(respBody, &result); err != nil {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
 		return
 	}
 
 	if result.ErrCode != corp.ErrCodeOK {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
 		err = &result.Error
 		return
 	}
@@ -254,10 +225,6 @@ func (srv *DefaultSuiteAccessTokenServer) getToken() (token suiteAccessTokenInfo
 	// 由于网络的延时, suite_access_token 过期时间留了一个缓冲区
 	switch {
 	case result.ExpiresIn > 31556952: // 60*60*24*365.2425
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
 		err = errors.New("expires_in too large: " + strconv.FormatInt(result.ExpiresIn, 10))
 		return
 	case result.ExpiresIn > 60*60:
@@ -269,10 +236,6 @@ func (srv *DefaultSuiteAccessTokenServer) getToken() (token suiteAccessTokenInfo
 	case result.ExpiresIn > 60:
 		result.ExpiresIn -= 10
 	default:
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
 		err = errors.New("expires_in too small: " + strconv.FormatInt(result.ExpiresIn, 10))
 		return
 	}
@@ -284,6 +247,7 @@ func (srv *DefaultSuiteAccessTokenServer) getToken() (token suiteAccessTokenInfo
 	// 更新缓存
 	srv.tokenCache.Lock()
 	srv.tokenCache.Token = result.suiteAccessTokenInfo.Token
+	srv.tokenCache.ExpiresAt = time.Now().Add(time.Duration(result.suiteAccessTokenInfo.ExpiresIn) * time.Second)
 	srv.tokenCache.Unlock()
 
 	token = result.suiteAccessTokenInfo
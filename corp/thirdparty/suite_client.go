@@ -9,19 +9,23 @@ package thirdparty
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"net/url"
 	"reflect"
 
 	"github.com/c77cc/wechat/corp"
+	"github.com/c77cc/wechat/util"
 )
 
 type SuiteClient struct {
 	SuiteId string
 	SuiteAccessTokenServer
 	HttpClient *http.Client
+	Endpoint   *util.Endpoint // 如果为 nil 则用 corp.DefaultEndpoint; 获取 suite_access_token 的地址见 DefaultSuiteAccessTokenServer.SetEndpoint
+
+	ctx context.Context // 见 WithContext
 }
 
 // 创建一个新的 SuiteClient.
@@ -56,6 +60,11 @@ func NewSuiteClient(SuiteId string, SuiteAccessTokenServer SuiteAccessTokenServe
 //          ...
 //      }
 func (clt *SuiteClient) PostJSON(incompleteURL string, request interface{}, response interface{}) (err error) {
+	return clt.PostJSONContext(clt.Context(), incompleteURL, request, response)
+}
+
+// PostJSON 的 context 版本, ctx 用于取消请求或者设置请求的超时.
+func (clt *SuiteClient) PostJSONContext(ctx context.Context, incompleteURL string, request interface{}, response interface{}) (err error) {
 	buf := textBufferPool.Get().(*bytes.Buffer)
 	buf.Reset()
 	defer textBufferPool.Put(buf)
@@ -72,16 +81,16 @@ func (clt *SuiteClient) PostJSON(incompleteURL string, request interface{}, resp
 
 	hasRetried := false
 RETRY:
-	finalURL := incompleteURL + url.QueryEscape(token)
+	finalURL := clt.EndpointURL(incompleteURL) + url.QueryEscape(token)
 
-	httpResp, err := clt.HttpClient.Post(finalURL, "application/json; charset=utf-8", bytes.NewReader(requestBytes))
+	httpResp, err := util.HttpPostContext(ctx, clt.HttpClient, finalURL, "application/json; charset=utf-8", bytes.NewReader(requestBytes))
 	if err != nil {
 		return
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("http.Status: %s", httpResp.Status)
+		return &util.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 	}
 
 	if err = json.NewDecoder(httpResp.Body).Decode(response); err != nil {
@@ -135,6 +144,11 @@ RETRY:
 //          ...
 //      }
 func (clt *SuiteClient) GetJSON(incompleteURL string, response interface{}) (err error) {
+	return clt.GetJSONContext(clt.Context(), incompleteURL, response)
+}
+
+// GetJSON 的 context 版本, ctx 用于取消请求或者设置请求的超时.
+func (clt *SuiteClient) GetJSONContext(ctx context.Context, incompleteURL string, response interface{}) (err error) {
 	token, err := clt.Token()
 	if err != nil {
 		return
@@ -142,16 +156,16 @@ func (clt *SuiteClient) GetJSON(incompleteURL string, response interface{}) (err
 
 	hasRetried := false
 RETRY:
-	finalURL := incompleteURL + url.QueryEscape(token)
+	finalURL := clt.EndpointURL(incompleteURL) + url.QueryEscape(token)
 
-	httpResp, err := clt.HttpClient.Get(finalURL)
+	httpResp, err := util.HttpGetContext(ctx, clt.HttpClient, finalURL)
 	if err != nil {
 		return
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("http.Status: %s", httpResp.Status)
+		return &util.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 	}
 
 	if err = json.NewDecoder(httpResp.Body).Decode(response); err != nil {
@@ -0,0 +1,28 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package thirdparty
+
+import (
+	"context"
+)
+
+// 返回 SuiteClient 绑定的 context, 没有绑定则返回 context.Background().
+func (clt *SuiteClient) Context() context.Context {
+	if clt.ctx != nil {
+		return clt.ctx
+	}
+	return context.Background()
+}
+
+// 返回一个绑定了 ctx 的 SuiteClient 的浅拷贝, 通过它发起的请求(包括高层次的封装方法)都受 ctx 控制.
+func (clt *SuiteClient) WithContext(ctx context.Context) *SuiteClient {
+	if ctx == nil {
+		panic("nil context")
+	}
+	clt2 := *clt
+	clt2.ctx = ctx
+	return &clt2
+}
@@ -0,0 +1,185 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mch
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+// 解析 PKCS#12 格式的证书(apiclient_cert.p12), password 为证书的密码, 默认为商户号 mch_id.
+//  依赖 golang.org/x/crypto/pkcs12, 只支持 SHA1 + 3DES/RC2 的传统加密算法,
+//  OpenSSL 3 默认导出的 AES 加密的 p12 需要加上 -legacy 重新导出.
+func LoadPKCS12(data []byte, password string) (cert tls.Certificate, err error) {
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return
+	}
+	return x509KeyPairFromPEMBlocks(blocks)
+}
+
+// 从 pkcs12.ToPEM 返回的 pem.Block 里找出私钥和对应的证书.
+//  p12 文件里证书的顺序是不确定的, 和私钥匹配的证书才是商户证书, 其他的证书作为证书链.
+func x509KeyPairFromPEMBlocks(blocks []*pem.Block) (cert tls.Certificate, err error) {
+	var certBlocks []*pem.Block
+	var keyPEM []byte
+	for _, block := range blocks {
+		switch block.Type {
+		case "CERTIFICATE":
+			certBlocks = append(certBlocks, block)
+		case "PRIVATE KEY":
+			if keyPEM == nil {
+				keyPEM = pem.EncodeToMemory(block)
+			}
+		}
+	}
+	if len(certBlocks) == 0 || keyPEM == nil {
+		err = errors.New("pkcs12: no certificate or private key found")
+		return
+	}
+
+	for i, leaf := range certBlocks {
+		certPEM := pem.EncodeToMemory(leaf)
+		for j, block := range certBlocks {
+			if j != i {
+				certPEM = append(certPEM, pem.EncodeToMemory(block)...)
+			}
+		}
+		if cert, err = tls.X509KeyPair(certPEM, keyPEM); err == nil {
+			return
+		}
+	}
+	err = errors.New("pkcs12: no certificate matches the private key")
+	return
+}
+
+// 从文件读取 PKCS#12 格式的证书, 见 LoadPKCS12.
+func LoadPKCS12File(filename, password string) (cert tls.Certificate, err error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	return LoadPKCS12(data, password)
+}
+
+// 可以在运行时更换的证书, 用于证书到期后的热更新, 见 NewTLSHttpClientWithCertHolder.
+type CertHolder struct {
+	mutex sync.RWMutex
+	cert  *tls.Certificate
+}
+
+func NewCertHolder(cert tls.Certificate) *CertHolder {
+	return &CertHolder{
+		cert: &cert,
+	}
+}
+
+// 更换证书, 之后新建立的连接使用新的证书.
+func (holder *CertHolder) SetCertificate(cert tls.Certificate) {
+	holder.mutex.Lock()
+	holder.cert = &cert
+	holder.mutex.Unlock()
+}
+
+// 从 PEM 格式的证书和私钥文件加载新的证书.
+func (holder *CertHolder) LoadX509KeyPair(certFile, keyFile string) (err error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return
+	}
+	holder.SetCertificate(cert)
+	return
+}
+
+// 从 PKCS#12 格式的证书文件加载新的证书.
+func (holder *CertHolder) LoadPKCS12File(filename, password string) (err error) {
+	cert, err := LoadPKCS12File(filename, password)
+	if err != nil {
+		return
+	}
+	holder.SetCertificate(cert)
+	return
+}
+
+// 返回当前的证书.
+func (holder *CertHolder) Certificate() *tls.Certificate {
+	holder.mutex.RLock()
+	cert := holder.cert
+	holder.mutex.RUnlock()
+	return cert
+}
+
+// 实现 tls.Config.GetClientCertificate.
+func (holder *CertHolder) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if cert := holder.Certificate(); cert != nil {
+		return cert, nil
+	}
+	return &tls.Certificate{}, nil // 没有证书的时候不发送证书, 由服务器拒绝
+}
+
+// 按商户号保存多个商户的证书, 用于服务商或者同时管理多个商户的场景.
+//  商户号可以是 mch_id, 也可以是 sub_mch_id, 由使用者自己约定.
+//
+//  store := mch.NewCertStore()
+//  store.SetCertificate(mchId, cert)
+//  proxy := mch.NewProxy(apiKey, store.HttpClient(mchId))
+//  store.SetCertificate(mchId, newCert) // 证书到期后热更新, proxy 不需要重新创建
+type CertStore struct {
+	mutex   sync.RWMutex
+	holders map[string]*CertHolder
+	clients map[string]*http.Client
+}
+
+func NewCertStore() *CertStore {
+	return &CertStore{
+		holders: make(map[string]*CertHolder),
+		clients: make(map[string]*http.Client),
+	}
+}
+
+// 设置(或者更换) mchId 的证书.
+func (store *CertStore) SetCertificate(mchId string, cert tls.Certificate) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if holder := store.holders[mchId]; holder != nil {
+		holder.SetCertificate(cert)
+		return
+	}
+	store.holders[mchId] = NewCertHolder(cert)
+}
+
+// 返回 mchId 的 CertHolder, 没有设置过证书则返回 nil.
+func (store *CertStore) CertHolder(mchId string) *CertHolder {
+	store.mutex.RLock()
+	holder := store.holders[mchId]
+	store.mutex.RUnlock()
+	return holder
+}
+
+// 返回使用 mchId 的证书的 http.Client, 同一个 mchId 返回同一个 http.Client, 这样可以复用连接.
+//  如果还没有设置 mchId 的证书则返回 nil.
+func (store *CertStore) HttpClient(mchId string) *http.Client {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if client := store.clients[mchId]; client != nil {
+		return client
+	}
+	holder := store.holders[mchId]
+	if holder == nil {
+		return nil
+	}
+	client := NewTLSHttpClientWithCertHolder(holder)
+	store.clients[mchId] = client
+	return client
+}
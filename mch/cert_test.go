@@ -0,0 +1,82 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mch
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestX509KeyPairFromPEMBlocks(t *testing.T) {
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	newCert := func(serial int64, cn string, key *ecdsa.PrivateKey, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: cn},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  parent == nil,
+			BasicConstraintsValid: true,
+		}
+		if parent == nil {
+			parent, parentKey = template, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+
+	caKey, leafKey := newKey(), newKey()
+	ca := newCert(1, "ca", caKey, nil, nil)
+	leaf := newCert(2, "1900000109", leafKey, ca, caKey)
+
+	keyDER, err := x509.MarshalECPrivateKey(leafKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 和 pkcs12.ToPEM 一样, 私钥的类型为 "PRIVATE KEY", CA 证书排在前面
+	blocks := []*pem.Block{
+		{Type: "CERTIFICATE", Bytes: ca.Raw},
+		{Type: "PRIVATE KEY", Bytes: keyDER},
+		{Type: "CERTIFICATE", Bytes: leaf.Raw},
+	}
+	cert, err := x509KeyPairFromPEMBlocks(blocks)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(cert.Certificate) != 2 || !bytes.Equal(cert.Certificate[0], leaf.Raw) || !bytes.Equal(cert.Certificate[1], ca.Raw) {
+		t.Error("the leaf certificate should come first, followed by the chain")
+	}
+
+	// 没有和私钥匹配的证书
+	if _, err = x509KeyPairFromPEMBlocks(blocks[:2]); err == nil {
+		t.Error("x509KeyPairFromPEMBlocks should fail without the matching certificate")
+	}
+	if _, err = x509KeyPairFromPEMBlocks(blocks[1:2]); err == nil {
+		t.Error("x509KeyPairFromPEMBlocks should fail without certificates")
+	}
+}
@@ -9,17 +9,25 @@ package mch
 
 import (
 	"bytes"
+	"context"
 	"errors"
-	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/c77cc/util"
+	wechatutil "github.com/c77cc/wechat/util"
 )
 
 type Proxy struct {
-	apiKey     string
-	httpClient *http.Client
+	apiKey      string
+	httpClient  *http.Client
+	endpoint    *wechatutil.Endpoint    // 见 SetEndpoint
+	retryPolicy *wechatutil.RetryPolicy // 见 SetRetryPolicy
+	sandbox     *sandbox                // 见 EnableSandbox
+	observer    CallObserver            // 见 SetCallObserver
+
+	ctx context.Context // 见 WithContext
 }
 
 // 创建一个新的 Proxy.
@@ -35,9 +43,33 @@ func NewProxy(apiKey string, httpClient *http.Client) *Proxy {
 	}
 }
 
+// 返回 Proxy 的 API密钥, 用于签名.
+func (proxy *Proxy) APIKey() string {
+	return proxy.apiKey
+}
+
 // 微信支付通用请求方法.
 //  注意: err == nil 表示协议状态都为 SUCCESS.
 func (proxy *Proxy) PostXML(url string, req map[string]string) (resp map[string]string, err error) {
+	return proxy.PostXMLContext(proxy.Context(), url, req)
+}
+
+// PostXML 的 context 版本, ctx 用于取消请求或者设置请求的超时.
+func (proxy *Proxy) PostXMLContext(ctx context.Context, url string, req map[string]string) (resp map[string]string, err error) {
+	if proxy.observer != nil {
+		startTime, rawURL, rawReq := time.Now(), url, req
+		defer func() {
+			proxy.observe(rawURL, rawReq, startTime, resp, err)
+		}()
+	}
+
+	apiKey := proxy.apiKey
+	if proxy.sandbox != nil {
+		if url, req, apiKey, err = proxy.sandboxRequest(ctx, url, req); err != nil {
+			return
+		}
+	}
+
 	bodyBuf := textBufferPool.Get().(*bytes.Buffer)
 	bodyBuf.Reset()
 	defer textBufferPool.Put(bodyBuf)
@@ -49,14 +81,25 @@ func (proxy *Proxy) PostXML(url string, req map[string]string) (resp map[string]
 	LogInfoln("[WECHAT_DEBUG] request url:", url)
 	LogInfoln("[WECHAT_DEBUG] request xml:", bodyBuf.String())
 
-	httpResp, err := proxy.httpClient.Post(url, "text/xml; charset=utf-8", bodyBuf)
+	retryPolicy := proxy.RetryPolicy()
+	attempt := 0
+RETRY:
+	attempt++
+	httpResp, err := wechatutil.HttpPostContext(ctx, proxy.httpClient, proxy.EndpointURL(url), "text/xml; charset=utf-8", bytes.NewReader(bodyBuf.Bytes()))
 	if err != nil {
 		return
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("http.Status: %s", httpResp.Status)
+		if retryPolicy.ShouldRetryHTTPStatus(ctx, attempt, httpResp.StatusCode) {
+			LogInfoln("[WECHAT_RETRY] http.Status:", httpResp.Status, ", attempt:", attempt)
+			if err = retryPolicy.Wait(ctx, attempt); err != nil {
+				return
+			}
+			goto RETRY
+		}
+		err = &wechatutil.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 		return
 	}
 
@@ -90,9 +133,17 @@ func (proxy *Proxy) PostXML(url string, req map[string]string) (resp map[string]
 		err = errors.New("no sign parameter")
 		return
 	}
-	signature2 := Sign(resp, proxy.apiKey, nil)
+	// 返回的数据没有 sign_type 的时候和请求的签名类型一致
+	signType, ok := resp["sign_type"]
+	if !ok {
+		signType = req["sign_type"]
+	}
+	signature2, err := SignWithType(resp, apiKey, signType)
+	if err != nil {
+		return
+	}
 	if signature1 != signature2 {
-		err = fmt.Errorf("check signature failed, \r\ninput: %q, \r\nlocal: %q", signature1, signature2)
+		err = &wechatutil.SignatureError{Name: "sign", Input: signature1, Local: signature2}
 		return
 	}
 	return
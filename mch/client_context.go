@@ -0,0 +1,30 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mch
+
+import (
+	"context"
+)
+
+// 返回 Proxy 绑定的 context, 没有绑定则返回 context.Background().
+func (proxy *Proxy) Context() context.Context {
+	if proxy.ctx != nil {
+		return proxy.ctx
+	}
+	return context.Background()
+}
+
+// 返回一个绑定了 ctx 的 Proxy 的浅拷贝, 通过它发起的请求都受 ctx 控制.
+//  例如:
+//      resp, err := pay.OrderQuery(proxy.WithContext(ctx), req)
+func (proxy *Proxy) WithContext(ctx context.Context) *Proxy {
+	if ctx == nil {
+		panic("nil context")
+	}
+	proxy2 := *proxy
+	proxy2.ctx = ctx
+	return &proxy2
+}
@@ -0,0 +1,63 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mch
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/c77cc/util"
+	wechatutil "github.com/c77cc/wechat/util"
+)
+
+// 下载对账单, 资金账单等文件的通用请求方法.
+//  成功的时候返回文件的原始数据; 失败的时候微信支付返回的是 XML 格式的错误信息, 转换为 *Error 返回.
+func (proxy *Proxy) Download(url string, req map[string]string) (data []byte, err error) {
+	return proxy.DownloadContext(proxy.Context(), url, req)
+}
+
+// Download 的 context 版本, ctx 用于取消请求或者设置请求的超时.
+func (proxy *Proxy) DownloadContext(ctx context.Context, url string, req map[string]string) (data []byte, err error) {
+	if proxy.sandbox != nil {
+		if url, req, _, err = proxy.sandboxRequest(ctx, url, req); err != nil {
+			return
+		}
+	}
+
+	bodyBuf := bytes.NewBuffer(make([]byte, 0, 1024))
+	if err = util.FormatMapToXML(bodyBuf, req); err != nil {
+		return
+	}
+
+	httpResp, err := wechatutil.HttpPostContext(ctx, proxy.httpClient, proxy.EndpointURL(url), "text/xml; charset=utf-8", bodyBuf)
+	if err != nil {
+		return
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		err = &wechatutil.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
+		return
+	}
+
+	respBody, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return
+	}
+
+	var result Error
+	if err = xml.Unmarshal(respBody, &result); err == nil {
+		err = &result
+		return
+	}
+
+	data = respBody
+	err = nil
+	return
+}
@@ -0,0 +1,28 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mch
+
+import (
+	wechatutil "github.com/c77cc/wechat/util"
+)
+
+// 默认的 api 地址配置, 所有没有通过 SetEndpoint 指定配置的 Proxy 都使用这个配置.
+//  沒有加锁, 请确保在初始化阶段修改!
+var DefaultEndpoint = &wechatutil.Endpoint{}
+
+// 设置 proxy 的 api 地址配置, 如果 endpoint == nil 则使用 DefaultEndpoint.
+//  沒有加锁, 请确保在初始化阶段调用!
+func (proxy *Proxy) SetEndpoint(endpoint *wechatutil.Endpoint) {
+	proxy.endpoint = endpoint
+}
+
+// 把官方的 api 地址 rawURL 转换为 proxy 配置的地址, 见 util.Endpoint.
+func (proxy *Proxy) EndpointURL(rawURL string) string {
+	if proxy.endpoint != nil {
+		return proxy.endpoint.URL(rawURL)
+	}
+	return DefaultEndpoint.URL(rawURL)
+}
@@ -18,3 +18,14 @@ type Error struct {
 func (e *Error) Error() string {
 	return fmt.Sprintf("return_code: %q, return_msg: %q", e.ReturnCode, e.ReturnMsg)
 }
+
+// 业务结果 result_code 不为 SUCCESS 的错误.
+type BizError struct {
+	ResultCode string `xml:"result_code"            json:"result_code"`
+	ErrCode    string `xml:"err_code,omitempty"     json:"err_code,omitempty"`
+	ErrCodeDes string `xml:"err_code_des,omitempty" json:"err_code_des,omitempty"`
+}
+
+func (e *BizError) Error() string {
+	return fmt.Sprintf("result_code: %q, err_code: %q, err_code_des: %q", e.ResultCode, e.ErrCode, e.ErrCodeDes)
+}
@@ -21,8 +21,37 @@ func NewTLSHttpClient(certFile, keyFile string) (httpClient *http.Client, err er
 	tlsConfig := &tls.Config{
 		Certificates: []tls.Certificate{cert},
 	}
+	return newTLSHttpClient(tlsConfig), nil
+}
+
+// NewTLSHttpClientPKCS12 根据 PKCS#12 格式的证书(apiclient_cert.p12)创建支持双向证书认证的 http.Client,
+// password 为证书的密码, 默认为商户号 mch_id.
+func NewTLSHttpClientPKCS12(p12File, password string) (httpClient *http.Client, err error) {
+	cert, err := LoadPKCS12File(p12File, password)
+	if err != nil {
+		return
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+	return newTLSHttpClient(tlsConfig), nil
+}
+
+// NewTLSHttpClientWithCertHolder 创建支持双向证书认证的 http.Client, 每次建立连接的时候从 holder 获取证书,
+// 所以 holder 更新证书后不需要重新创建 http.Client 和 Proxy.
+//  NOTE: 已经建立的连接还是用旧的证书, 直到连接被关闭.
+func NewTLSHttpClientWithCertHolder(holder *CertHolder) *http.Client {
+	if holder == nil {
+		panic("nil CertHolder")
+	}
+	tlsConfig := &tls.Config{
+		GetClientCertificate: holder.GetClientCertificate,
+	}
+	return newTLSHttpClient(tlsConfig)
+}
 
-	httpClient = &http.Client{
+func newTLSHttpClient(tlsConfig *tls.Config) *http.Client {
+	return &http.Client{
 		Transport: &http.Transport{
 			Proxy: http.ProxyFromEnvironment,
 			Dial: (&net.Dialer{
@@ -34,5 +63,4 @@ func NewTLSHttpClient(certFile, keyFile string) (httpClient *http.Client, err er
 		},
 		Timeout: 60 * time.Second,
 	}
-	return
 }
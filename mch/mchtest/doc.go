@@ -0,0 +1,16 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+// 模拟微信支付 api 的测试服务器, 用于离线的集成测试.
+//
+//  srv := mchtest.NewServer("appid", "mchid", "apikey")
+//  defer srv.Close()
+//
+//  proxy := mch.NewProxy("apikey", nil)
+//  proxy.SetEndpoint(srv.Endpoint())
+//
+//  resp, err := pay.UnifiedOrder(proxy, req)
+//  srv.Pay(req["out_trade_no"]) // 模拟用户支付成功
+package mchtest
@@ -0,0 +1,452 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mchtest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/c77cc/util"
+	"github.com/c77cc/wechat/mch"
+	wechatutil "github.com/c77cc/wechat/util"
+)
+
+// 订单状态
+const (
+	TradeStateSuccess    = "SUCCESS"    // 支付成功
+	TradeStateRefund     = "REFUND"     // 转入退款
+	TradeStateNotPay     = "NOTPAY"     // 未支付
+	TradeStateClosed     = "CLOSED"     // 已关闭
+	TradeStateRevoked    = "REVOKED"    // 已撤销(刷卡支付)
+	TradeStateUserPaying = "USERPAYING" // 用户支付中
+	TradeStatePayError   = "PAYERROR"   // 支付失败
+)
+
+var beijingLocation = time.FixedZone("Asia/Shanghai", 8*60*60)
+
+// 测试服务器上的订单
+type Order struct {
+	OutTradeNo    string
+	TransactionId string
+	TradeType     string
+	TradeState    string
+	Body          string
+	OpenId        string
+	TotalFee      int64
+	RefundFee     int64
+	PrepayId      string
+	TimeEnd       string // yyyyMMddHHmmss, 北京时间
+}
+
+// 模拟微信支付 api 的测试服务器.
+//  测试服务器校验请求的 appid, mch_id 和签名, 并用 apiKey 对返回的数据签名.
+type Server struct {
+	*httptest.Server
+
+	appId  string
+	mchId  string
+	apiKey string
+
+	mutex          sync.Mutex
+	handlers       map[string]HandlerFunc // path => HandlerFunc
+	seq            int
+	orders         map[string]*Order
+	injectedErrors []string
+}
+
+// 创建并启动一个新的测试服务器, 用完后需要调用 Close.
+func NewServer(appId, mchId, apiKey string) *Server {
+	srv := &Server{
+		appId:  appId,
+		mchId:  mchId,
+		apiKey: apiKey,
+		orders: make(map[string]*Order),
+
+		handlers: make(map[string]HandlerFunc),
+	}
+
+	srv.HandleFunc("/pay/unifiedorder", srv.serveUnifiedOrder)
+	srv.HandleFunc("/pay/orderquery", srv.serveOrderQuery)
+	srv.HandleFunc("/pay/closeorder", srv.serveCloseOrder)
+	srv.HandleFunc("/secapi/pay/refund", srv.serveRefund)
+	srv.HandleFunc("/pay/refundquery", srv.serveRefundQuery)
+	srv.HandleFunc("/pay/micropay", srv.serveMicroPay)
+	srv.HandleFunc("/secapi/pay/reverse", srv.serveReverse)
+	srv.HandleFunc("/payitil/report", srv.serveReport)
+
+	srv.Server = httptest.NewServer(http.HandlerFunc(srv.dispatch))
+	return srv
+}
+
+// 返回指向这个测试服务器的 Endpoint, 一般用于 mch.Proxy.SetEndpoint.
+func (srv *Server) Endpoint() *wechatutil.Endpoint {
+	return &wechatutil.Endpoint{
+		MchAPIBaseURL: srv.URL,
+	}
+}
+
+// 业务处理函数, req 是已经校验过签名的请求参数,
+// 返回的 resp 会被自动加上 return_code, appid, mch_id, nonce_str, sign 等字段.
+type HandlerFunc func(req map[string]string) (resp map[string]string)
+
+// 注册一个 api, 可以用来模拟本包没有实现的 api 或者覆盖默认的实现.
+//  path 为 api 的路径, 比如 "/pay/orderquery".
+func (srv *Server) HandleFunc(path string, handler HandlerFunc) {
+	srv.mutex.Lock()
+	srv.handlers[path] = handler
+	srv.mutex.Unlock()
+}
+
+func (srv *Server) dispatch(w http.ResponseWriter, r *http.Request) {
+	srv.mutex.Lock()
+	handler := srv.handlers[r.URL.Path]
+	srv.mutex.Unlock()
+
+	if handler == nil {
+		http.NotFound(w, r)
+		return
+	}
+	srv.serve(w, r, handler)
+}
+
+// 注入一个业务错误, 下一个请求直接返回 result_code == FAIL 和 err_code == errCode.
+//  多次调用按照调用的顺序依次返回.
+func (srv *Server) InjectError(errCode string) {
+	srv.mutex.Lock()
+	srv.injectedErrors = append(srv.injectedErrors, errCode)
+	srv.mutex.Unlock()
+}
+
+// 获取订单, 返回的是订单的拷贝.
+func (srv *Server) Order(outTradeNo string) (order Order, ok bool) {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	if o := srv.orders[outTradeNo]; o != nil {
+		order, ok = *o, true
+	}
+	return
+}
+
+// 模拟用户支付成功.
+func (srv *Server) Pay(outTradeNo string) bool {
+	return srv.SetTradeState(outTradeNo, TradeStateSuccess)
+}
+
+// 修改订单的状态, 订单不存在返回 false.
+func (srv *Server) SetTradeState(outTradeNo, tradeState string) bool {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	order := srv.orders[outTradeNo]
+	if order == nil {
+		return false
+	}
+	order.TradeState = tradeState
+	if tradeState == TradeStateSuccess {
+		srv.paid(order)
+	}
+	return true
+}
+
+// 设置订单为支付成功, 调用者需要加锁.
+func (srv *Server) paid(order *Order) {
+	order.TradeState = TradeStateSuccess
+	if order.TransactionId == "" {
+		srv.seq++
+		order.TransactionId = "4200000000" + strconv.Itoa(1000000+srv.seq)
+	}
+	order.TimeEnd = time.Now().In(beijingLocation).Format("20060102150405")
+}
+
+func (srv *Server) serve(w http.ResponseWriter, r *http.Request, handler HandlerFunc) {
+	req, err := util.ParseXMLToMap(r.Body)
+	if err != nil {
+		srv.writeFail(w, "XML格式错误")
+		return
+	}
+	if req["appid"] != srv.appId {
+		srv.writeFail(w, "appid不存在")
+		return
+	}
+	if req["mch_id"] != srv.mchId {
+		srv.writeFail(w, "mch_id不存在")
+		return
+	}
+	if req["nonce_str"] == "" {
+		srv.writeFail(w, "nonce_str不能为空")
+		return
+	}
+	if sign, err := mch.SignWithType(req, srv.apiKey, req["sign_type"]); err != nil || req["sign"] != sign {
+		srv.writeFail(w, "签名错误")
+		return
+	}
+
+	var resp map[string]string
+
+	srv.mutex.Lock()
+	if len(srv.injectedErrors) > 0 {
+		resp = fail(srv.injectedErrors[0])
+		srv.injectedErrors = srv.injectedErrors[1:]
+	}
+	srv.mutex.Unlock()
+
+	if resp == nil {
+		resp = handler(req)
+	}
+
+	resp["return_code"] = mch.ReturnCodeSuccess
+	resp["return_msg"] = "OK"
+	resp["appid"] = srv.appId
+	resp["mch_id"] = srv.mchId
+	resp["nonce_str"] = strconv.FormatInt(time.Now().UnixNano(), 36)
+	resp["sign"], _ = mch.SignWithType(resp, srv.apiKey, req["sign_type"])
+	srv.writeXML(w, resp)
+}
+
+func (srv *Server) writeFail(w http.ResponseWriter, returnMsg string) {
+	srv.writeXML(w, map[string]string{
+		"return_code": mch.ReturnCodeFail,
+		"return_msg":  returnMsg,
+	})
+}
+
+func (srv *Server) writeXML(w http.ResponseWriter, resp map[string]string) {
+	buf := bytes.NewBuffer(make([]byte, 0, 512))
+	if err := util.FormatMapToXML(buf, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+func success() map[string]string {
+	return map[string]string{
+		"result_code": mch.ResultCodeSuccess,
+	}
+}
+
+func fail(errCode string) map[string]string {
+	return map[string]string{
+		"result_code":  mch.ResultCodeFail,
+		"err_code":     errCode,
+		"err_code_des": errCode,
+	}
+}
+
+func (srv *Server) serveUnifiedOrder(req map[string]string) map[string]string {
+	outTradeNo := req["out_trade_no"]
+	totalFee, err := strconv.ParseInt(req["total_fee"], 10, 64)
+	if outTradeNo == "" || err != nil || totalFee <= 0 {
+		return fail("PARAM_ERROR")
+	}
+
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	order := srv.orders[outTradeNo]
+	switch {
+	case order == nil:
+		srv.seq++
+		order = &Order{
+			OutTradeNo: outTradeNo,
+			TradeType:  req["trade_type"],
+			TradeState: TradeStateNotPay,
+			Body:       req["body"],
+			OpenId:     req["openid"],
+			TotalFee:   totalFee,
+			PrepayId:   "wx" + strconv.Itoa(1000000+srv.seq),
+		}
+		srv.orders[outTradeNo] = order
+	case order.TradeState == TradeStateSuccess:
+		return fail("ORDERPAID")
+	case order.TradeState == TradeStateClosed:
+		return fail("ORDERCLOSED")
+	case order.TotalFee != totalFee:
+		return fail("OUT_TRADE_NO_USED")
+	}
+
+	resp := success()
+	resp["trade_type"] = order.TradeType
+	resp["prepay_id"] = order.PrepayId
+	if order.TradeType == "NATIVE" {
+		resp["code_url"] = "weixin://wxpay/bizpayurl?pr=" + order.PrepayId
+	}
+	return resp
+}
+
+// 根据 out_trade_no 或者 transaction_id 查找订单, 调用者需要加锁.
+func (srv *Server) findOrder(req map[string]string) *Order {
+	if outTradeNo := req["out_trade_no"]; outTradeNo != "" {
+		return srv.orders[outTradeNo]
+	}
+	if transactionId := req["transaction_id"]; transactionId != "" {
+		for _, order := range srv.orders {
+			if order.TransactionId == transactionId {
+				return order
+			}
+		}
+	}
+	return nil
+}
+
+func (srv *Server) serveOrderQuery(req map[string]string) map[string]string {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	order := srv.findOrder(req)
+	if order == nil {
+		return fail("ORDERNOTEXIST")
+	}
+
+	resp := success()
+	resp["out_trade_no"] = order.OutTradeNo
+	resp["trade_state"] = order.TradeState
+	resp["total_fee"] = strconv.FormatInt(order.TotalFee, 10)
+	resp["trade_type"] = order.TradeType
+	if order.OpenId != "" {
+		resp["openid"] = order.OpenId
+	}
+	if order.TransactionId != "" {
+		resp["transaction_id"] = order.TransactionId
+		resp["time_end"] = order.TimeEnd
+		resp["bank_type"] = "CFT"
+		resp["cash_fee"] = resp["total_fee"]
+	}
+	return resp
+}
+
+func (srv *Server) serveCloseOrder(req map[string]string) map[string]string {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	order := srv.orders[req["out_trade_no"]]
+	switch {
+	case order == nil:
+		return fail("ORDERNOTEXIST")
+	case order.TradeState == TradeStateSuccess || order.TradeState == TradeStateRefund:
+		return fail("ORDERPAID")
+	}
+	order.TradeState = TradeStateClosed
+	return success()
+}
+
+func (srv *Server) serveRefund(req map[string]string) map[string]string {
+	refundFee, err := strconv.ParseInt(req["refund_fee"], 10, 64)
+	if err != nil || refundFee <= 0 || req["out_refund_no"] == "" {
+		return fail("PARAM_ERROR")
+	}
+
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	order := srv.findOrder(req)
+	switch {
+	case order == nil:
+		return fail("ORDERNOTEXIST")
+	case order.TradeState != TradeStateSuccess && order.TradeState != TradeStateRefund:
+		return fail("TRADE_STATE_ERROR")
+	case order.RefundFee+refundFee > order.TotalFee:
+		return fail("NOTENOUGH")
+	}
+	order.RefundFee += refundFee
+	order.TradeState = TradeStateRefund
+
+	srv.seq++
+	resp := success()
+	resp["transaction_id"] = order.TransactionId
+	resp["out_trade_no"] = order.OutTradeNo
+	resp["out_refund_no"] = req["out_refund_no"]
+	resp["refund_id"] = "5000000000" + strconv.Itoa(1000000+srv.seq)
+	resp["refund_fee"] = strconv.FormatInt(refundFee, 10)
+	resp["total_fee"] = strconv.FormatInt(order.TotalFee, 10)
+	resp["cash_fee"] = resp["total_fee"]
+	return resp
+}
+
+func (srv *Server) serveRefundQuery(req map[string]string) map[string]string {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	order := srv.findOrder(req)
+	if order == nil || order.RefundFee == 0 {
+		return fail("REFUNDNOTEXIST")
+	}
+
+	resp := success()
+	resp["transaction_id"] = order.TransactionId
+	resp["out_trade_no"] = order.OutTradeNo
+	resp["total_fee"] = strconv.FormatInt(order.TotalFee, 10)
+	resp["refund_count"] = "1"
+	resp["refund_fee_0"] = strconv.FormatInt(order.RefundFee, 10)
+	resp["refund_status_0"] = "SUCCESS"
+	return resp
+}
+
+// 刷卡支付.
+//  auth_code 以 USERPAYING 开头的时候模拟需要用户输入密码的情况, 返回 USERPAYING,
+//  之后可以调用 Pay 或者 SetTradeState 改变订单的状态.
+func (srv *Server) serveMicroPay(req map[string]string) map[string]string {
+	outTradeNo := req["out_trade_no"]
+	totalFee, err := strconv.ParseInt(req["total_fee"], 10, 64)
+	if outTradeNo == "" || req["auth_code"] == "" || err != nil || totalFee <= 0 {
+		return fail("PARAM_ERROR")
+	}
+
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	order := srv.orders[outTradeNo]
+	if order != nil {
+		return fail("OUT_TRADE_NO_USED")
+	}
+	order = &Order{
+		OutTradeNo: outTradeNo,
+		TradeType:  "MICROPAY",
+		TradeState: TradeStateUserPaying,
+		Body:       req["body"],
+		TotalFee:   totalFee,
+	}
+	srv.orders[outTradeNo] = order
+
+	if strings.HasPrefix(req["auth_code"], "USERPAYING") {
+		return fail("USERPAYING")
+	}
+	srv.paid(order)
+
+	resp := success()
+	resp["out_trade_no"] = order.OutTradeNo
+	resp["transaction_id"] = order.TransactionId
+	resp["total_fee"] = strconv.FormatInt(order.TotalFee, 10)
+	resp["time_end"] = order.TimeEnd
+	resp["trade_type"] = order.TradeType
+	return resp
+}
+
+func (srv *Server) serveReverse(req map[string]string) map[string]string {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+
+	order := srv.findOrder(req)
+	if order == nil {
+		return fail("ORDERNOTEXIST")
+	}
+	order.TradeState = TradeStateRevoked
+
+	resp := success()
+	resp["recall"] = "N"
+	return resp
+}
+
+func (srv *Server) serveReport(req map[string]string) map[string]string {
+	return success()
+}
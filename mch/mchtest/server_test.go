@@ -0,0 +1,78 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mchtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/c77cc/wechat/mch"
+	"github.com/c77cc/wechat/mch/pay"
+)
+
+func TestServer(t *testing.T) {
+	srv := NewServer("appid", "mchid", "apikey")
+	defer srv.Close()
+
+	proxy := mch.NewProxy("apikey", nil)
+	proxy.SetEndpoint(srv.Endpoint())
+	clt := pay.NewClient(proxy, "appid", "mchid")
+
+	resp, err := clt.UnifiedOrder(&pay.UnifiedOrderRequest{
+		Body:           "body",
+		OutTradeNo:     "1001",
+		TotalFee:       100,
+		SpbillCreateIP: "127.0.0.1",
+		NotifyURL:      "http://localhost/notify",
+		TradeType:      "NATIVE",
+		ProductId:      "product",
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if resp.PrepayId == "" || resp.CodeURL == "" {
+		t.Errorf("unexpected UnifiedOrder response: %+v", resp)
+		return
+	}
+
+	query, err := clt.OrderQuery(&pay.OrderQueryRequest{OutTradeNo: "1001"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if query.TradeState != TradeStateNotPay {
+		t.Errorf("trade_state = %q, want %q", query.TradeState, TradeStateNotPay)
+		return
+	}
+
+	if !srv.Pay("1001") {
+		t.Error("Pay(1001) failed")
+		return
+	}
+	if query, err = clt.OrderQuery(&pay.OrderQueryRequest{OutTradeNo: "1001"}); err != nil {
+		t.Error(err)
+		return
+	}
+	if query.TradeState != TradeStateSuccess || query.TransactionId == "" || query.TotalFee != 100 {
+		t.Errorf("unexpected OrderQuery response: %+v", query)
+		return
+	}
+	// time_end 是北京时间
+	if d := time.Since(query.TimeEnd); d < -time.Minute || d > time.Minute {
+		t.Errorf("time_end = %v, want about now", query.TimeEnd)
+		return
+	}
+
+	srv.InjectError("SYSTEMERROR")
+	if _, err = clt.OrderQuery(&pay.OrderQueryRequest{OutTradeNo: "1001"}); err == nil {
+		t.Error("OrderQuery should return the injected error")
+		return
+	}
+	if _, err = clt.OrderQuery(&pay.OrderQueryRequest{OutTradeNo: "1002"}); err == nil {
+		t.Error("OrderQuery should fail for unknown order")
+	}
+}
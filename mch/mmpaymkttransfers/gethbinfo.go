@@ -0,0 +1,16 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mmpaymkttransfers
+
+import (
+	"github.com/c77cc/wechat/mch"
+)
+
+// 查询红包记录, 普通红包和裂变红包都用这个接口查询.
+//  NOTE: 请求需要双向证书
+func GetHBInfo(proxy *mch.Proxy, req map[string]string) (resp map[string]string, err error) {
+	return proxy.PostXML("https://api.mch.weixin.qq.com/mmpaymkttransfers/gethbinfo", req)
+}
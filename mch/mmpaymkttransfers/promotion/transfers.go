@@ -7,10 +7,11 @@ package promotion
 
 import (
 	"github.com/c77cc/wechat/mch"
+	wechatutil "github.com/c77cc/wechat/util"
 )
 
 // 企业付款.
 //  NOTE: 请求需要双向证书
 func Transfers(proxy *mch.Proxy, req map[string]string) (resp map[string]string, err error) {
-	return proxy.PostXML("https://api.mch.weixin.qq.com/mmpaymkttransfers/promotion/transfers", req)
+	return proxy.PostXMLContext(wechatutil.NonIdempotent(proxy.Context()), "https://api.mch.weixin.qq.com/mmpaymkttransfers/promotion/transfers", req)
 }
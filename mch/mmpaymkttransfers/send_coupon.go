@@ -7,9 +7,10 @@ package mmpaymkttransfers
 
 import (
 	"github.com/c77cc/wechat/mch"
+	wechatutil "github.com/c77cc/wechat/util"
 )
 
 // 发放代金券.
 func SendCoupon(proxy *mch.Proxy, req map[string]string) (resp map[string]string, err error) {
-	return proxy.PostXML("https://api.mch.weixin.qq.com/mmpaymkttransfers/send_coupon", req)
+	return proxy.PostXMLContext(wechatutil.NonIdempotent(proxy.Context()), "https://api.mch.weixin.qq.com/mmpaymkttransfers/send_coupon", req)
 }
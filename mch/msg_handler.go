@@ -26,4 +26,6 @@ type Request struct {
 
 	RawMsgXML []byte            // 消息的 XML 文本
 	Msg       map[string]string // 解析后的消息
+
+	ReqInfoXML []byte // 退款结果通知解密后的 req_info, 其他消息为 nil
 }
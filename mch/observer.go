@@ -0,0 +1,49 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mch
+
+import (
+	"time"
+)
+
+// 一次 PostXML 调用的统计信息.
+type CallStat struct {
+	URL       string            // 官方的 api 地址, 没有经过 Endpoint 和仿真测试模式的转换
+	Request   map[string]string // 请求参数
+	Response  map[string]string // 返回的数据, 请求失败的时候可能为 nil
+	Err       error             // PostXML 返回的错误
+	StartTime time.Time         // 开始调用的时间
+	Duration  time.Duration     // 调用的耗时, 包括重试
+}
+
+// PostXML 调用的观察者, 用于测速上报, 监控等.
+//  ObserveCall 在 PostXML 返回之前同步调用, 不能阻塞, 也不能修改 stat 里面的 map.
+type CallObserver interface {
+	ObserveCall(stat *CallStat)
+}
+
+type CallObserverFunc func(stat *CallStat)
+
+func (fn CallObserverFunc) ObserveCall(stat *CallStat) {
+	fn(stat)
+}
+
+// 设置 proxy 的 CallObserver, observer == nil 表示不观察.
+//  沒有加锁, 请确保在初始化阶段调用!
+func (proxy *Proxy) SetCallObserver(observer CallObserver) {
+	proxy.observer = observer
+}
+
+func (proxy *Proxy) observe(url string, req map[string]string, startTime time.Time, resp map[string]string, err error) {
+	proxy.observer.ObserveCall(&CallStat{
+		URL:       url,
+		Request:   req,
+		Response:  resp,
+		Err:       err,
+		StartTime: startTime,
+		Duration:  time.Since(startTime),
+	})
+}
@@ -0,0 +1,345 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package pay
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 对账单里的时间格式, 北京时间
+const BillTimeLayout = "2006-01-02 15:04:05"
+
+// 对账单的一行记录, 金额的单位为分.
+//  不同 bill_type 的对账单列不一样, 对账单里没有的列为零值.
+type BillRecord struct {
+	TradeTime         time.Time // 交易时间
+	AppId             string    // 公众账号ID
+	MchId             string    // 商户号
+	SubMchId          string    // 子商户号
+	DeviceInfo        string    // 设备号
+	TransactionId     string    // 微信订单号
+	OutTradeNo        string    // 商户订单号
+	OpenId            string    // 用户标识
+	TradeType         string    // 交易类型
+	TradeState        string    // 交易状态
+	BankType          string    // 付款银行
+	FeeType           string    // 货币种类
+	TotalFee          int64     // 总金额
+	CouponFee         int64     // 代金券或立减优惠金额
+	RefundApplyTime   time.Time // 退款申请时间
+	RefundSuccessTime time.Time // 退款成功时间
+	RefundId          string    // 微信退款单号
+	OutRefundNo       string    // 商户退款单号
+	RefundFee         int64     // 退款金额
+	CouponRefundFee   int64     // 代金券或立减优惠退款金额
+	RefundType        string    // 退款类型
+	RefundStatus      string    // 退款状态
+	Body              string    // 商品名称
+	Attach            string    // 商户数据包
+	ServiceFee        int64     // 手续费
+	Rate              string    // 费率, 比如 "0.60%"
+}
+
+// 对账单末尾的汇总数据, 金额的单位为分.
+type BillSummary struct {
+	TotalCount           int64 // 总交易单数
+	TotalFee             int64 // 总交易额
+	TotalRefundFee       int64 // 总退款金额
+	TotalCouponRefundFee int64 // 总代金券或立减优惠退款金额
+	TotalServiceFee      int64 // 手续费总金额
+}
+
+// 流式的对账单解析器, 支持 ALL, SUCCESS, REFUND 三种对账单, 支持 gzip 压缩(tar_type=GZIP)的对账单.
+//
+//  data, err := pay.DownloadBill(req, nil)
+//  br, err := pay.NewBillReader(bytes.NewReader(data))
+//  for {
+//      record, err := br.Read()
+//      if err == io.EOF {
+//          break
+//      }
+//      ...
+//  }
+//  summary := br.Summary()
+type BillReader struct {
+	scanner *bufio.Scanner
+	columns map[string]int // 列名 => 列的序号
+	summary *BillSummary
+	err     error
+}
+
+// 创建一个 BillReader, 读取并解析表头. r 是 gzip 格式的时候自动解压.
+func NewBillReader(r io.Reader) (br *BillReader, err error) {
+	bufReader := bufio.NewReader(r)
+	if magic, _ := bufReader.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(bufReader)
+		if err != nil {
+			return nil, err
+		}
+		r = gzipReader
+	} else {
+		r = bufReader
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+
+	br = &BillReader{
+		scanner: scanner,
+	}
+	line, err := br.nextLine()
+	if err != nil {
+		if err == io.EOF {
+			err = errors.New("empty bill")
+		}
+		return nil, err
+	}
+	line = strings.TrimPrefix(line, "\ufeff") // UTF-8 BOM
+	br.columns = make(map[string]int)
+	for i, name := range strings.Split(line, ",") {
+		br.columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := br.columns["交易时间"]; !ok {
+		return nil, fmt.Errorf("invalid bill header: %s", line)
+	}
+	return br, nil
+}
+
+// 读取下一条记录, 没有更多的记录时返回 io.EOF, 这时可以通过 Summary 获取汇总数据.
+func (br *BillReader) Read() (record *BillRecord, err error) {
+	if br.err != nil {
+		return nil, br.err
+	}
+
+	line, err := br.nextLine()
+	if err != nil {
+		if err == io.EOF {
+			err = errors.New("unexpected EOF: no bill summary")
+		}
+		br.err = err
+		return nil, err
+	}
+
+	// 记录的每一列都以 ` 开头, 汇总数据的表头不是
+	if !strings.HasPrefix(line, "`") {
+		if err = br.readSummary(line); err != nil {
+			br.err = err
+			return nil, err
+		}
+		br.err = io.EOF
+		return nil, io.EOF
+	}
+
+	d := &billDecoder{columns: br.columns, fields: splitBillLine(line)}
+	record = &BillRecord{
+		TradeTime:         d.Time("交易时间"),
+		AppId:             d.String("公众账号ID"),
+		MchId:             d.String("商户号"),
+		SubMchId:          d.String("子商户号", "特约商户号"),
+		DeviceInfo:        d.String("设备号"),
+		TransactionId:     d.String("微信订单号"),
+		OutTradeNo:        d.String("商户订单号"),
+		OpenId:            d.String("用户标识"),
+		TradeType:         d.String("交易类型"),
+		TradeState:        d.String("交易状态"),
+		BankType:          d.String("付款银行"),
+		FeeType:           d.String("货币种类"),
+		TotalFee:          d.Fen("总金额", "应结订单金额"),
+		CouponFee:         d.Fen("代金券或立减优惠金额", "代金券金额"),
+		RefundApplyTime:   d.Time("退款申请时间"),
+		RefundSuccessTime: d.Time("退款成功时间"),
+		RefundId:          d.String("微信退款单号"),
+		OutRefundNo:       d.String("商户退款单号"),
+		RefundFee:         d.Fen("退款金额"),
+		CouponRefundFee:   d.Fen("代金券或立减优惠退款金额", "充值券退款金额"),
+		RefundType:        d.String("退款类型"),
+		RefundStatus:      d.String("退款状态"),
+		Body:              d.String("商品名称"),
+		Attach:            d.String("商户数据包"),
+		ServiceFee:        d.Fen("手续费"),
+		Rate:              d.String("费率"),
+	}
+	if d.err != nil {
+		br.err = d.err
+		return nil, d.err
+	}
+	return record, nil
+}
+
+// 对账单的汇总数据, Read 返回 io.EOF 之前为 nil.
+func (br *BillReader) Summary() *BillSummary {
+	return br.summary
+}
+
+func (br *BillReader) readSummary(header string) (err error) {
+	line, err := br.nextLine()
+	if err != nil {
+		if err == io.EOF {
+			err = errors.New("unexpected EOF: no bill summary")
+		}
+		return
+	}
+
+	columns := make(map[string]int)
+	for i, name := range strings.Split(header, ",") {
+		columns[strings.TrimSpace(name)] = i
+	}
+	d := &billDecoder{columns: columns, fields: splitBillLine(line)}
+	summary := &BillSummary{
+		TotalCount:           d.Int64("总交易单数"),
+		TotalFee:             d.Fen("总交易额", "应结订单总金额"),
+		TotalRefundFee:       d.Fen("总退款金额", "退款总金额"),
+		TotalCouponRefundFee: d.Fen("总代金券或立减优惠退款金额", "充值券退款总金额"),
+		TotalServiceFee:      d.Fen("手续费总金额"),
+	}
+	if d.err != nil {
+		return d.err
+	}
+	br.summary = summary
+	return
+}
+
+// 读取下一个非空行
+func (br *BillReader) nextLine() (string, error) {
+	for br.scanner.Scan() {
+		line := bytes.TrimSpace(br.scanner.Bytes())
+		if len(line) > 0 {
+			return string(line), nil
+		}
+	}
+	if err := br.scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
+
+// 解析整个对账单.
+func ParseBill(data []byte) (records []*BillRecord, summary *BillSummary, err error) {
+	br, err := NewBillReader(bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	for {
+		record, err := br.Read()
+		if err != nil {
+			if err == io.EOF {
+				return records, br.Summary(), nil
+			}
+			return nil, nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// "`a,`b,`c" => ["a", "b", "c"]
+func splitBillLine(line string) []string {
+	return strings.Split(strings.TrimPrefix(line, "`"), ",`")
+}
+
+type billDecoder struct {
+	columns map[string]int
+	fields  []string
+	err     error
+}
+
+// 返回第一个存在的列的值
+func (d *billDecoder) String(names ...string) string {
+	for _, name := range names {
+		if i, ok := d.columns[name]; ok && i < len(d.fields) {
+			return strings.TrimSpace(d.fields[i])
+		}
+	}
+	return ""
+}
+
+func (d *billDecoder) Int64(names ...string) int64 {
+	value := d.String(names...)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil && d.err == nil {
+		d.err = fmt.Errorf("invalid %s: %q", names[0], value)
+	}
+	return n
+}
+
+// 对账单里的金额单位为元, 转换为分
+func (d *billDecoder) Fen(names ...string) int64 {
+	value := d.String(names...)
+	if value == "" {
+		return 0
+	}
+	n, err := parseFen(value)
+	if err != nil && d.err == nil {
+		d.err = fmt.Errorf("invalid %s: %q", names[0], value)
+	}
+	return n
+}
+
+func (d *billDecoder) Time(names ...string) time.Time {
+	value := d.String(names...)
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.ParseInLocation(BillTimeLayout, value, beijingLocation)
+	if err != nil && d.err == nil {
+		d.err = fmt.Errorf("invalid %s: %q", names[0], value)
+	}
+	return t
+}
+
+// "12.34" => 1234, 超过两位的小数四舍五入, 不经过 float 避免精度问题.
+func parseFen(value string) (fen int64, err error) {
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	yuan, decimal := value, ""
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		yuan, decimal = value[:i], value[i+1:]
+	}
+	if yuan == "" && decimal == "" {
+		return 0, errors.New("empty amount")
+	}
+	for _, c := range yuan + decimal {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid amount: %s", value)
+		}
+	}
+	if yuan != "" {
+		if fen, err = strconv.ParseInt(yuan, 10, 64); err != nil {
+			return
+		}
+		// 留出小数部分的空间, 避免乘以 100 之后溢出
+		if fen > (math.MaxInt64-100)/100 {
+			return 0, fmt.Errorf("amount out of range: %s", value)
+		}
+	}
+	fen *= 100
+
+	switch {
+	case len(decimal) == 1:
+		fen += int64(decimal[0]-'0') * 10
+	case len(decimal) >= 2:
+		fen += int64(decimal[0]-'0')*10 + int64(decimal[1]-'0')
+		if len(decimal) > 2 && decimal[2] >= '5' {
+			fen++
+		}
+	}
+	if negative {
+		fen = -fen
+	}
+	return
+}
@@ -0,0 +1,181 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package pay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestParseFen(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{"0", 0},
+		{"1", 100},
+		{"1.", 100},
+		{".5", 50},
+		{"0.01", 1},
+		{"1.2", 120},
+		{"12.34", 1234},
+		{"0.004", 0},
+		{"0.005", 1},
+		{"0.995", 100},
+		{"1.23456", 123},
+		{"-0.01", -1},
+		{"-12.345", -1235},
+		{"-.5", -50},
+		{"92233720368547757.99", 9223372036854775799},
+	}
+	for _, test := range tests {
+		have, err := parseFen(test.value)
+		if err != nil {
+			t.Errorf("parseFen(%q) failed: %v", test.value, err)
+			continue
+		}
+		if have != test.want {
+			t.Errorf("parseFen(%q) = %d, want %d", test.value, have, test.want)
+		}
+	}
+
+	for _, value := range []string{"", "-", ".", "-.", "abc", "1.2.3", "1,000.00", " 1", "1 ", "+1", "--1", "1.-5", "1e2", "¥1", "92233720368547758", "100000000000000000"} {
+		if have, err := parseFen(value); err == nil {
+			t.Errorf("parseFen(%q) = %d, want error", value, have)
+		}
+	}
+}
+
+const testBillAll = "\ufeff交易时间,公众账号ID,商户号,子商户号,设备号,微信订单号,商户订单号,用户标识,交易类型,交易状态,付款银行,货币种类,总金额,代金券或立减优惠金额,微信退款单号,商户退款单号,退款金额,代金券或立减优惠退款金额,退款类型,退款状态,商品名称,商户数据包,手续费,费率\r\n" +
+	"`2014-11-10 16:33:45,`wx2421b1c4370ec43b,`10000100,`0,`1000,`1001690740201411100005734289,`1415640626,`085e9858e3ba5186aafcbaed1,`MICROPAY,`SUCCESS,`OTHERS,`CNY,`0.01,`0.0,`0,`0,`0,`0,`,`,`被扫支付测试,`订单额外描述,`0.00000,`0.60%\r\n" +
+	"`2014-11-10 16:46:14,`wx2421b1c4370ec43b,`10000100,`0,`1000,`1002780740201411100005729794,`1415635270,`085e9858e90ca40c0b5aee463,`MICROPAY,`REFUND,`OTHERS,`CNY,`1.23,`0.0,`2002780740201411100005729794,`R1415635270,`0.50,`0.00,`ORIGINAL,`SUCCESS,`被扫支付测试,`订单额外描述,`-0.003,`0.60%\r\n" +
+	"总交易单数,总交易额,总退款金额,总代金券或立减优惠退款金额,手续费总金额\r\n" +
+	"`2,`1.24,`0.50,`0.00,`0.00700\r\n"
+
+const testBillRefund = "交易时间,公众账号ID,商户号,子商户号,设备号,微信订单号,商户订单号,用户标识,交易类型,交易状态,付款银行,货币种类,总金额,代金券或立减优惠金额,退款申请时间,退款成功时间,微信退款单号,商户退款单号,退款金额,代金券或立减优惠退款金额,退款类型,退款状态,商品名称,商户数据包,手续费,费率\n" +
+	"`2014-11-10 16:46:14,`wx2421b1c4370ec43b,`10000100,`0,`1000,`1002780740201411100005729794,`1415635270,`085e9858e90ca40c0b5aee463,`MICROPAY,`REFUND,`OTHERS,`CNY,`1.23,`0.0,`2014-11-11 10:00:00,`2014-11-11 10:00:05,`2002780740201411100005729794,`R1415635270,`0.50,`0.00,`ORIGINAL,`SUCCESS,`被扫支付测试,`订单额外描述,`-0.003,`0.60%\n" +
+	"\n" +
+	"总交易单数,总交易额,总退款金额,总代金券或立减优惠退款金额,手续费总金额\n" +
+	"`1,`1.23,`0.50,`0.00,`-0.003\n"
+
+// 新版的对账单, 金额的列名和旧版不一样
+const testBillAllV2 = "交易时间,公众账号ID,商户号,特约商户号,设备号,微信订单号,商户订单号,用户标识,交易类型,交易状态,付款银行,货币种类,应结订单金额,代金券金额,微信退款单号,商户退款单号,退款金额,充值券退款金额,退款类型,退款状态,商品名称,商户数据包,手续费,费率,订单金额,申请退款金额,费率备注\r\n" +
+	"`2019-05-21 10:00:00,`wx2421b1c4370ec43b,`10000100,`1900000109,`,`4200000301201905210000000001,`O1,`oUpF8uMuAJO_M2pxb1Q9zNjWeS6o,`JSAPI,`REFUND,`CMB_DEBIT,`CNY,`2.00,`0.50,`50000000382019052100000000001,`R1,`1.00,`0.10,`ORIGINAL,`SUCCESS,`商品,`,`0.01200,`0.60%,`2.50,`1.00,`\r\n" +
+	"总交易单数,应结订单总金额,退款总金额,充值券退款总金额,手续费总金额,订单总金额,申请退款总金额\r\n" +
+	"`1,`2.00,`1.00,`0.10,`0.01200,`2.50,`1.00\r\n"
+
+func gzipBytes(data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestParseBill(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		records int
+		check   func(records []*BillRecord) bool
+		summary BillSummary
+	}{
+		{
+			name:    "ALL",
+			data:    []byte(testBillAll),
+			records: 2,
+			check: func(records []*BillRecord) bool {
+				r := records[1]
+				return records[0].TotalFee == 1 && records[0].Rate == "0.60%" && records[0].Body == "被扫支付测试" &&
+					r.OutTradeNo == "1415635270" && r.TradeState == "REFUND" && r.TotalFee == 123 &&
+					r.RefundFee == 50 && r.OutRefundNo == "R1415635270" && r.ServiceFee == 0 &&
+					r.TradeTime.Equal(time.Date(2014, 11, 10, 8, 46, 14, 0, time.UTC))
+			},
+			summary: BillSummary{TotalCount: 2, TotalFee: 124, TotalRefundFee: 50, TotalServiceFee: 1},
+		},
+		{
+			name:    "ALL v2",
+			data:    []byte(testBillAllV2),
+			records: 1,
+			check: func(records []*BillRecord) bool {
+				r := records[0]
+				return r.SubMchId == "1900000109" && r.TotalFee == 200 && r.CouponFee == 50 &&
+					r.RefundFee == 100 && r.CouponRefundFee == 10 && r.ServiceFee == 1
+			},
+			summary: BillSummary{TotalCount: 1, TotalFee: 200, TotalRefundFee: 100, TotalCouponRefundFee: 10, TotalServiceFee: 1},
+		},
+		{
+			name:    "REFUND",
+			data:    []byte(testBillRefund),
+			records: 1,
+			check: func(records []*BillRecord) bool {
+				r := records[0]
+				return r.RefundFee == 50 && r.RefundStatus == "SUCCESS" && r.RefundType == "ORIGINAL" &&
+					r.RefundApplyTime.Equal(time.Date(2014, 11, 11, 2, 0, 0, 0, time.UTC)) &&
+					r.RefundSuccessTime.Equal(time.Date(2014, 11, 11, 2, 0, 5, 0, time.UTC))
+			},
+			summary: BillSummary{TotalCount: 1, TotalFee: 123, TotalRefundFee: 50, TotalServiceFee: 0},
+		},
+	}
+	for _, test := range tests {
+		for _, compressed := range []bool{false, true} {
+			data := test.data
+			if compressed {
+				data = gzipBytes(data)
+			}
+			records, summary, err := ParseBill(data)
+			if err != nil {
+				t.Errorf("%s (gzip=%v): ParseBill failed: %v", test.name, compressed, err)
+				continue
+			}
+			if len(records) != test.records {
+				t.Errorf("%s (gzip=%v): got %d records, want %d", test.name, compressed, len(records), test.records)
+				continue
+			}
+			if !test.check(records) {
+				t.Errorf("%s (gzip=%v): unexpected records: %+v", test.name, compressed, records)
+			}
+			if *summary != test.summary {
+				t.Errorf("%s (gzip=%v): summary = %+v, want %+v", test.name, compressed, *summary, test.summary)
+			}
+		}
+	}
+}
+
+func TestBillReaderErrors(t *testing.T) {
+	if _, err := NewBillReader(bytes.NewReader(nil)); err == nil {
+		t.Error("empty bill should fail")
+	}
+	if _, err := NewBillReader(bytes.NewReader([]byte("<xml><return_code>FAIL</return_code></xml>"))); err == nil {
+		t.Error("error response should fail")
+	}
+
+	// 没有汇总数据
+	data := testBillAll[:bytes.LastIndex([]byte(testBillAll), []byte("总交易单数"))]
+	br, err := NewBillReader(bytes.NewReader([]byte(data)))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for {
+		if _, err = br.Read(); err != nil {
+			break
+		}
+	}
+	if err == io.EOF {
+		t.Error("bill without summary should fail")
+	}
+
+	// 金额格式错误
+	data = testBillRefund[:bytes.Index([]byte(testBillRefund), []byte("`1.23"))] + "`1.2x" +
+		testBillRefund[bytes.Index([]byte(testBillRefund), []byte("`1.23"))+len("`1.23"):]
+	if _, _, err = ParseBill([]byte(data)); err == nil {
+		t.Error("invalid amount should fail")
+	}
+}
@@ -0,0 +1,171 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package pay
+
+import (
+	"context"
+
+	"github.com/c77cc/wechat/mch"
+	wechatutil "github.com/c77cc/wechat/util"
+)
+
+// 类型化的支付接口, 自动填充 appid, mch_id, nonce_str 并且签名.
+//  底层还是调用 UnifiedOrder, OrderQuery 等基于 map[string]string 的函数.
+//  业务结果 result_code 不为 SUCCESS 的时候返回 *mch.BizError, 同时 resp 也是有效的.
+type Client struct {
+	*mch.Proxy
+	AppId    string // 公众账号ID, 服务商模式下为服务商的 appid
+	MchId    string // 商户号, 服务商模式下为服务商的商户号
+	SubAppId string // 服务商模式下子商户的公众账号ID, 可以为空
+	SubMchId string // 服务商模式下子商户的商户号, 非服务商模式为空
+	SignType string // 签名类型, mch.SignTypeMD5 或者 mch.SignTypeHMACSHA256, 为空时默认为 MD5
+}
+
+func NewClient(proxy *mch.Proxy, appId, mchId string) *Client {
+	if proxy == nil {
+		panic("nil mch.Proxy")
+	}
+	return &Client{
+		Proxy: proxy,
+		AppId: appId,
+		MchId: mchId,
+	}
+}
+
+// 返回一个绑定了 ctx 的 Client 的浅拷贝, 通过它发起的请求都受 ctx 控制, 见 mch.Proxy.WithContext.
+func (clt *Client) WithContext(ctx context.Context) *Client {
+	clt2 := *clt
+	clt2.Proxy = clt.Proxy.WithContext(ctx)
+	return &clt2
+}
+
+// 返回服务商模式下子商户的 Client, 它是 clt 的浅拷贝, 请求会自动填充 sub_appid 和 sub_mch_id.
+//
+//  subClient := providerClient.WithSubMerchant(subAppId, subMchId)
+//  resp, err := subClient.UnifiedOrder(req)
+func (clt *Client) WithSubMerchant(subAppId, subMchId string) *Client {
+	clt2 := *clt
+	clt2.SubAppId = subAppId
+	clt2.SubMchId = subMchId
+	return &clt2
+}
+
+// 填充公共参数, 签名后调用 fn.
+func (clt *Client) post(fn func(*mch.Proxy, map[string]string) (map[string]string, error),
+	req map[string]string) (resp map[string]string, err error) {
+
+	if err = clt.sign(req); err != nil {
+		return
+	}
+	return fn(clt.Proxy, req)
+}
+
+// 填充 appid, mch_id, sub_appid, sub_mch_id, sign_type, nonce_str 并签名.
+func (clt *Client) sign(req map[string]string) (err error) {
+	if req["appid"] == "" {
+		req["appid"] = clt.AppId
+	}
+	if req["mch_id"] == "" {
+		req["mch_id"] = clt.MchId
+	}
+	if req["sub_appid"] == "" && clt.SubAppId != "" {
+		req["sub_appid"] = clt.SubAppId
+	}
+	if req["sub_mch_id"] == "" && clt.SubMchId != "" {
+		req["sub_mch_id"] = clt.SubMchId
+	}
+	if req["sign_type"] == "" && clt.SignType != "" {
+		req["sign_type"] = clt.SignType
+	}
+	req["nonce_str"] = wechatutil.NonceStr()
+	req["sign"], err = mch.SignWithType(req, clt.APIKey(), req["sign_type"])
+	return
+}
+
+// 统一下单.
+func (clt *Client) UnifiedOrder(req *UnifiedOrderRequest) (resp *UnifiedOrderResponse, err error) {
+	m, err := clt.post(UnifiedOrder, req.toMap())
+	if err != nil {
+		return
+	}
+	resp = &UnifiedOrderResponse{}
+	return resp, decodeResponse(m, resp)
+}
+
+// 订单查询.
+func (clt *Client) OrderQuery(req *OrderQueryRequest) (resp *OrderQueryResponse, err error) {
+	m, err := clt.post(OrderQuery, req.toMap())
+	if err != nil {
+		return
+	}
+	resp = &OrderQueryResponse{}
+	return resp, decodeResponse(m, resp)
+}
+
+// 关闭订单.
+func (clt *Client) CloseOrder(req *CloseOrderRequest) (resp *CloseOrderResponse, err error) {
+	m, err := clt.post(CloseOrder, req.toMap())
+	if err != nil {
+		return
+	}
+	resp = &CloseOrderResponse{}
+	return resp, decodeResponse(m, resp)
+}
+
+// 申请退款.
+//  NOTE: 请求需要双向证书.
+func (clt *Client) Refund(req *RefundRequest) (resp *RefundResponse, err error) {
+	params := req.toMap()
+	if params["op_user_id"] == "" {
+		params["op_user_id"] = clt.MchId
+	}
+	m, err := clt.post(Refund, params)
+	if err != nil {
+		return
+	}
+	resp = &RefundResponse{}
+	return resp, decodeResponse(m, resp)
+}
+
+// 退款查询.
+func (clt *Client) RefundQuery(req *RefundQueryRequest) (resp *RefundQueryResponse, err error) {
+	m, err := clt.post(RefundQuery, req.toMap())
+	if err != nil {
+		return
+	}
+	resp = &RefundQueryResponse{}
+	return resp, decodeResponse(m, resp)
+}
+
+// 提交被扫支付.
+func (clt *Client) MicroPay(req *MicroPayRequest) (resp *MicroPayResponse, err error) {
+	m, err := clt.post(MicroPay, req.toMap())
+	if err != nil {
+		return
+	}
+	resp = &MicroPayResponse{}
+	return resp, decodeResponse(m, resp)
+}
+
+// 撤销支付.
+//  NOTE: 请求需要双向证书.
+func (clt *Client) Reverse(req *ReverseRequest) (resp *ReverseResponse, err error) {
+	m, err := clt.post(Reverse, req.toMap())
+	if err != nil {
+		return
+	}
+	resp = &ReverseResponse{}
+	return resp, decodeResponse(m, resp)
+}
+
+// 下载对账单, 返回的数据可以用 NewBillReader 或者 ParseBill 解析.
+func (clt *Client) DownloadBill(req *DownloadBillRequest) (data []byte, err error) {
+	params := req.toMap()
+	if err = clt.sign(params); err != nil {
+		return
+	}
+	return clt.DownloadContext(clt.Context(), "https://api.mch.weixin.qq.com/pay/downloadbill", params)
+}
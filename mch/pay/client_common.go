@@ -6,19 +6,16 @@
 package pay
 
 import (
-	"bytes"
-	"encoding/xml"
-	"fmt"
-	"io/ioutil"
+	"context"
 	"net/http"
 
-	"github.com/c77cc/util"
 	"github.com/c77cc/wechat/mch"
+	wechatutil "github.com/c77cc/wechat/util"
 )
 
 // 统一下单.
 func UnifiedOrder(proxy *mch.Proxy, req map[string]string) (resp map[string]string, err error) {
-	return proxy.PostXML("https://api.mch.weixin.qq.com/pay/unifiedorder", req)
+	return proxy.PostXMLContext(wechatutil.NonIdempotent(proxy.Context()), "https://api.mch.weixin.qq.com/pay/unifiedorder", req)
 }
 
 // 订单查询.
@@ -34,7 +31,7 @@ func CloseOrder(proxy *mch.Proxy, req map[string]string) (resp map[string]string
 // 申请退款.
 //  NOTE: 请求需要双向证书.
 func Refund(proxy *mch.Proxy, req map[string]string) (resp map[string]string, err error) {
-	return proxy.PostXML("https://api.mch.weixin.qq.com/secapi/pay/refund", req)
+	return proxy.PostXMLContext(wechatutil.NonIdempotent(proxy.Context()), "https://api.mch.weixin.qq.com/secapi/pay/refund", req)
 }
 
 // 退款查询.
@@ -44,38 +41,25 @@ func RefundQuery(proxy *mch.Proxy, req map[string]string) (resp map[string]strin
 
 // 下载对账单.
 func DownloadBill(req map[string]string, httpClient *http.Client) (data []byte, err error) {
-	if httpClient == nil {
-		httpClient = http.DefaultClient
-	}
-
-	bodyBuf := bytes.NewBuffer(make([]byte, 0, 1024))
-	if err = util.FormatMapToXML(bodyBuf, req); err != nil {
-		return
-	}
-
-	httpResp, err := httpClient.Post("https://api.mch.weixin.qq.com/pay/downloadbill", "text/xml; charset=utf-8", bodyBuf)
-	if err != nil {
-		return
-	}
-	defer httpResp.Body.Close()
+	return DownloadBillContext(context.Background(), req, httpClient)
+}
 
-	if httpResp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("http.Status: %s", httpResp.Status)
-		return
-	}
+// DownloadBill 的 context 版本, ctx 用于取消请求或者设置请求的超时.
+func DownloadBillContext(ctx context.Context, req map[string]string, httpClient *http.Client) (data []byte, err error) {
+	return download(ctx, "https://api.mch.weixin.qq.com/pay/downloadbill", req, httpClient)
+}
 
-	respBody, err := ioutil.ReadAll(httpResp.Body)
-	if err != nil {
-		return
-	}
+// 下载资金账单.
+//  NOTE: 请求需要双向证书, 并且只支持 HMAC-SHA256 签名(sign_type=HMAC-SHA256).
+func DownloadFundFlow(req map[string]string, httpClient *http.Client) (data []byte, err error) {
+	return DownloadFundFlowContext(context.Background(), req, httpClient)
+}
 
-	var result mch.Error
-	if err = xml.Unmarshal(respBody, &result); err == nil {
-		err = &result
-		return
-	}
+// DownloadFundFlow 的 context 版本, ctx 用于取消请求或者设置请求的超时.
+func DownloadFundFlowContext(ctx context.Context, req map[string]string, httpClient *http.Client) (data []byte, err error) {
+	return download(ctx, "https://api.mch.weixin.qq.com/pay/downloadfundflow", req, httpClient)
+}
 
-	data = respBody
-	err = nil
-	return
+func download(ctx context.Context, url string, req map[string]string, httpClient *http.Client) (data []byte, err error) {
+	return mch.NewProxy("", httpClient).DownloadContext(ctx, url, req)
 }
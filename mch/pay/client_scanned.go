@@ -7,11 +7,12 @@ package pay
 
 import (
 	"github.com/c77cc/wechat/mch"
+	wechatutil "github.com/c77cc/wechat/util"
 )
 
 // 提交被扫支付.
 func MicroPay(proxy *mch.Proxy, req map[string]string) (resp map[string]string, err error) {
-	return proxy.PostXML("https://api.mch.weixin.qq.com/pay/micropay", req)
+	return proxy.PostXMLContext(wechatutil.NonIdempotent(proxy.Context()), "https://api.mch.weixin.qq.com/pay/micropay", req)
 }
 
 // 撤销支付.
@@ -0,0 +1,200 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package pay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/c77cc/wechat/mch"
+)
+
+const (
+	DefaultMicroPayPollInterval    = 5 * time.Second  // 默认的查询订单的间隔
+	DefaultMicroPayPollTimeout     = 30 * time.Second // 默认的等待用户支付的最长时间, 超时后撤销订单
+	DefaultMicroPayReverseAttempts = 5                // 默认的撤销订单的最多次数
+	DefaultMicroPayReverseInterval = time.Second      // 默认的重新撤销订单的间隔
+)
+
+// 付款码支付的最终结果
+type MicroPayOutcome int
+
+const (
+	MicroPayUnknown  MicroPayOutcome = iota // 结果未知, 撤销也没有成功, 需要人工处理或者稍后查询
+	MicroPayPaid                            // 支付成功
+	MicroPayFailed                          // 支付失败, 用户没有付款, 不需要撤销
+	MicroPayReversed                        // 支付没有成功, 订单已经撤销
+)
+
+func (outcome MicroPayOutcome) String() string {
+	switch outcome {
+	case MicroPayPaid:
+		return "paid"
+	case MicroPayFailed:
+		return "failed"
+	case MicroPayReversed:
+		return "reversed"
+	default:
+		return "unknown"
+	}
+}
+
+// 付款码支付的结果.
+type MicroPayResult struct {
+	Outcome MicroPayOutcome
+	Order   *OrderInfo // 支付成功时的订单信息, 其他情况可能为 nil
+	Err     error      // Outcome 不是 MicroPayPaid 的原因, 一般为最后一次请求的错误
+}
+
+// 付款码支付的流程: 提交付款码支付, 用户支付中或者结果未知的时候轮询订单, 超时后撤销订单.
+//
+//  flow := &pay.MicroPayFlow{Client: client}
+//  result := flow.Pay(ctx, &pay.MicroPayRequest{...})
+//  switch result.Outcome {
+//  case pay.MicroPayPaid:
+//      ...
+//  }
+type MicroPayFlow struct {
+	Client *Client
+
+	PollInterval    time.Duration // 查询订单的间隔, 为 0 时使用 DefaultMicroPayPollInterval
+	PollTimeout     time.Duration // 等待用户支付的最长时间, 为 0 时使用 DefaultMicroPayPollTimeout
+	ReverseAttempts int           // 撤销订单的最多次数, 为 0 时使用 DefaultMicroPayReverseAttempts
+	ReverseInterval time.Duration // 重新撤销订单的间隔, 为 0 时使用 DefaultMicroPayReverseInterval
+}
+
+// 执行付款码支付流程, req.OutTradeNo 不能为空, 用于查询和撤销订单.
+//  ctx 控制整个流程, ctx 取消后返回 MicroPayUnknown.
+func (flow *MicroPayFlow) Pay(ctx context.Context, req *MicroPayRequest) *MicroPayResult {
+	if req.OutTradeNo == "" {
+		return &MicroPayResult{Outcome: MicroPayFailed, Err: errors.New("empty OutTradeNo")}
+	}
+	clt := flow.Client.WithContext(ctx)
+
+	resp, err := clt.MicroPay(req)
+	if err == nil {
+		return &MicroPayResult{Outcome: MicroPayPaid, Order: &resp.OrderInfo}
+	}
+	if bizErr, ok := err.(*mch.BizError); ok {
+		switch bizErr.ErrCode {
+		case "USERPAYING", "SYSTEMERROR", "BANKERROR", "ORDERPAID":
+			// 需要查询订单确认结果
+		default:
+			return &MicroPayResult{Outcome: MicroPayFailed, Err: err}
+		}
+	}
+	// 其他错误(网络错误, 签名错误等)不能确定订单是否已经提交, 也需要查询订单
+
+	result := flow.poll(ctx, clt, req.OutTradeNo, err)
+	if result != nil {
+		return result
+	}
+	return flow.reverse(ctx, clt, req.OutTradeNo)
+}
+
+// 轮询订单, 返回 nil 表示需要撤销订单.
+func (flow *MicroPayFlow) poll(ctx context.Context, clt *Client, outTradeNo string, lastErr error) *MicroPayResult {
+	interval := flow.PollInterval
+	if interval <= 0 {
+		interval = DefaultMicroPayPollInterval
+	}
+	timeout := flow.PollTimeout
+	if timeout <= 0 {
+		timeout = DefaultMicroPayPollTimeout
+	}
+	deadline := time.Now().Add(timeout)
+
+	// 至少查询一次, 并且超时的时候再查询一次, 避免撤销在等待期间已经支付成功的订单
+	for {
+		wait := interval
+		if remaining := time.Until(deadline); remaining < wait {
+			wait = remaining
+		}
+		if wait > 0 {
+			if err := sleep(ctx, wait); err != nil {
+				return &MicroPayResult{Outcome: MicroPayUnknown, Err: err}
+			}
+		}
+
+		resp, err := clt.OrderQuery(&OrderQueryRequest{OutTradeNo: outTradeNo})
+		if err != nil {
+			mch.LogInfoln("[WECHAT_MICROPAY] out_trade_no:", outTradeNo, "orderquery error:", err)
+		} else {
+			switch resp.TradeState {
+			case "SUCCESS", "REFUND":
+				return &MicroPayResult{Outcome: MicroPayPaid, Order: &resp.OrderInfo}
+			case "REVOKED":
+				return &MicroPayResult{Outcome: MicroPayReversed, Err: lastErr}
+			case "CLOSED":
+				return &MicroPayResult{Outcome: MicroPayFailed, Err: fmt.Errorf("trade_state: %s", resp.TradeState)}
+			case "PAYERROR":
+				// 支付失败的订单也需要撤销
+				return nil
+			}
+			// USERPAYING, NOTPAY 继续等待
+		}
+		if !time.Now().Before(deadline) {
+			return nil
+		}
+	}
+}
+
+// 撤销订单, 返回 recall == Y 的时候需要继续撤销.
+func (flow *MicroPayFlow) reverse(ctx context.Context, clt *Client, outTradeNo string) *MicroPayResult {
+	attempts := flow.ReverseAttempts
+	if attempts <= 0 {
+		attempts = DefaultMicroPayReverseAttempts
+	}
+	interval := flow.ReverseInterval
+	if interval <= 0 {
+		interval = DefaultMicroPayReverseInterval
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			if err := sleep(ctx, interval); err != nil {
+				return &MicroPayResult{Outcome: MicroPayUnknown, Err: err}
+			}
+		}
+
+		var resp *ReverseResponse
+		resp, err = clt.Reverse(&ReverseRequest{OutTradeNo: outTradeNo})
+		if err == nil {
+			return &MicroPayResult{Outcome: MicroPayReversed}
+		}
+		mch.LogInfoln("[WECHAT_MICROPAY] out_trade_no:", outTradeNo, "reverse error:", err)
+		if resp != nil && !resp.Recall {
+			// 不需要重试的业务错误, 比如用户已经支付成功(撤销失败), 查询一次订单确认
+			break
+		}
+	}
+
+	resp, qerr := clt.OrderQuery(&OrderQueryRequest{OutTradeNo: outTradeNo})
+	if qerr == nil {
+		switch resp.TradeState {
+		case "SUCCESS", "REFUND":
+			return &MicroPayResult{Outcome: MicroPayPaid, Order: &resp.OrderInfo}
+		case "REVOKED":
+			return &MicroPayResult{Outcome: MicroPayReversed}
+		}
+	}
+	return &MicroPayResult{Outcome: MicroPayUnknown, Err: err}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,105 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package pay
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/c77cc/wechat/mch"
+	"github.com/c77cc/wechat/mch/mchtest"
+)
+
+func TestMicroPayFlow(t *testing.T) {
+	const (
+		appId  = "wx2421b1c4370ec43b"
+		mchId  = "10000100"
+		apiKey = "192006250b4c09247ec02edce69f6a2d"
+	)
+	srv := mchtest.NewServer(appId, mchId, apiKey)
+	defer srv.Close()
+
+	proxy := mch.NewProxy(apiKey, nil)
+	proxy.SetEndpoint(srv.Endpoint())
+	clt := NewClient(proxy, appId, mchId)
+
+	tests := []struct {
+		name         string
+		authCode     string
+		payAfter     time.Duration // 大于 0 时在这个时间后模拟用户支付成功
+		pollInterval time.Duration
+		pollTimeout  time.Duration
+		outcome      MicroPayOutcome
+		tradeState   string
+	}{
+		{
+			name:       "paid",
+			authCode:   "120061098828009406",
+			outcome:    MicroPayPaid,
+			tradeState: mchtest.TradeStateSuccess,
+		},
+		{
+			name:         "userpaying then paid",
+			authCode:     "USERPAYING",
+			payAfter:     time.Millisecond * 30,
+			pollInterval: time.Millisecond * 10,
+			pollTimeout:  time.Second,
+			outcome:      MicroPayPaid,
+			tradeState:   mchtest.TradeStateSuccess,
+		},
+		{
+			name:         "userpaying then reversed",
+			authCode:     "USERPAYING",
+			pollInterval: time.Millisecond * 10,
+			pollTimeout:  time.Millisecond * 50,
+			outcome:      MicroPayReversed,
+			tradeState:   mchtest.TradeStateRevoked,
+		},
+		{
+			// 查询间隔大于超时时间, 超时的时候也要查询一次订单, 不能撤销已经支付成功的订单
+			name:         "poll interval longer than timeout",
+			authCode:     "USERPAYING",
+			payAfter:     time.Millisecond * 10,
+			pollInterval: time.Hour,
+			pollTimeout:  time.Millisecond * 50,
+			outcome:      MicroPayPaid,
+			tradeState:   mchtest.TradeStateSuccess,
+		},
+	}
+	for i, test := range tests {
+		outTradeNo := fmt.Sprintf("micropay%d", i)
+		if test.payAfter > 0 {
+			timer := time.AfterFunc(test.payAfter, func() { srv.Pay(outTradeNo) })
+			defer timer.Stop()
+		}
+
+		flow := &MicroPayFlow{
+			Client:          clt,
+			PollInterval:    test.pollInterval,
+			PollTimeout:     test.pollTimeout,
+			ReverseInterval: time.Millisecond * 10,
+		}
+		result := flow.Pay(context.Background(), &MicroPayRequest{
+			Body:           "image形象店-深圳腾大- QQ公仔",
+			OutTradeNo:     outTradeNo,
+			TotalFee:       1,
+			SpbillCreateIP: "8.8.8.8",
+			AuthCode:       test.authCode,
+		})
+		if result.Outcome != test.outcome {
+			t.Errorf("%s: got outcome %s, want %s, err: %v", test.name, result.Outcome, test.outcome, result.Err)
+			continue
+		}
+		if test.outcome == MicroPayPaid && (result.Order == nil || result.Order.OutTradeNo != outTradeNo) {
+			t.Errorf("%s: unexpected order: %+v", test.name, result.Order)
+		}
+		if order, _ := srv.Order(outTradeNo); order.TradeState != test.tradeState {
+			t.Errorf("%s: got trade_state %s, want %s", test.name, order.TradeState, test.tradeState)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package pay
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/c77cc/util"
+	"github.com/c77cc/wechat/mch"
+	wechatutil "github.com/c77cc/wechat/util"
+)
+
+// 扫码支付模式一, 用户扫描 mch.NativeURL1 生成的二维码后微信支付回调的参数.
+type NativeProductRequest struct {
+	AppId       string // 公众账号ID
+	MchId       string // 商户号
+	OpenId      string // 用户标识
+	IsSubscribe bool   // 是否关注公众账号
+	ProductId   string // 商品ID
+	SubAppId    string // 子商户公众账号ID, 服务商模式
+	SubMchId    string // 子商户号, 服务商模式
+}
+
+// 根据商品生成统一下单的请求参数, 不需要设置 TradeType, ProductId 和 OpenId.
+//  返回错误的时候回复微信支付 result_code 为 FAIL; 如果错误是 *mch.BizError, 则 ErrCodeDes 作为 err_code_des 展示给用户,
+//  其他的错误只记录日志, 展示给用户的是固定的提示, 避免泄漏内部信息.
+type NativeProductHandlerFunc func(r *NativeProductRequest) (*UnifiedOrderRequest, error)
+
+// 展示给用户的默认错误提示
+const nativeErrCodeDes = "系统繁忙, 请稍后再试"
+
+var errNativeNilOrder = errors.New("NativeProductHandlerFunc returned nil UnifiedOrderRequest")
+
+var _ mch.MessageHandler = (*NativeProductHandler)(nil)
+
+// 扫码支付模式一的回调处理, 调用统一下单并回复 prepay_id.
+//
+//  handler := pay.NewNativeProductHandler(client, func(r *pay.NativeProductRequest) (*pay.UnifiedOrderRequest, error) {
+//      return &pay.UnifiedOrderRequest{Body: "...", OutTradeNo: "...", TotalFee: 100, ...}, nil
+//  })
+//  messageServer := mch.NewDefaultMessageServer(appId, mchId, apiKey, handler)
+type NativeProductHandler struct {
+	clt     *Client
+	handler NativeProductHandlerFunc
+}
+
+func NewNativeProductHandler(clt *Client, handler NativeProductHandlerFunc) *NativeProductHandler {
+	if clt == nil {
+		panic("nil Client")
+	}
+	if handler == nil {
+		panic("nil NativeProductHandlerFunc")
+	}
+	return &NativeProductHandler{
+		clt:     clt,
+		handler: handler,
+	}
+}
+
+func (h *NativeProductHandler) ServeMessage(w http.ResponseWriter, r *mch.Request) {
+	if r.ReqInfoXML != nil || r.Msg["sign"] == "" {
+		mch.LogInfoln("[WECHAT_PAY_NATIVE] error:", errUnsignedRequest)
+		WriteNotifyResponse(w, errUnsignedRequest)
+		return
+	}
+
+	req := &NativeProductRequest{
+		AppId:       r.Msg["appid"],
+		MchId:       r.Msg["mch_id"],
+		OpenId:      r.Msg["openid"],
+		IsSubscribe: r.Msg["is_subscribe"] == "Y",
+		ProductId:   r.Msg["product_id"],
+		SubAppId:    r.Msg["sub_appid"],
+		SubMchId:    r.Msg["sub_mch_id"],
+	}
+
+	prepayId, err := h.unifiedOrder(req)
+	if err != nil {
+		mch.LogInfoln("[WECHAT_PAY_NATIVE] product_id:", req.ProductId, "error:", err)
+	}
+
+	resp := make(map[string]string, 8)
+	resp["return_code"] = mch.ReturnCodeSuccess
+	resp["appid"] = req.AppId
+	resp["mch_id"] = req.MchId
+	if req.SubMchId != "" {
+		resp["sub_appid"] = req.SubAppId
+		resp["sub_mch_id"] = req.SubMchId
+	}
+	resp["nonce_str"] = wechatutil.NonceStr()
+	if err != nil {
+		resp["result_code"] = mch.ResultCodeFail
+		resp["err_code_des"] = nativeErrCodeDes
+		if bizErr, ok := err.(*mch.BizError); ok && bizErr.ErrCodeDes != "" {
+			resp["err_code_des"] = bizErr.ErrCodeDes
+		}
+	} else {
+		resp["prepay_id"] = prepayId
+		resp["result_code"] = mch.ResultCodeSuccess
+	}
+	if resp["sign"], err = mch.SignWithType(resp, h.clt.APIKey(), r.Msg["sign_type"]); err != nil {
+		mch.LogInfoln("[WECHAT_PAY_NATIVE] sign error:", err)
+		WriteNotifyResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	util.FormatMapToXML(w, resp)
+}
+
+func (h *NativeProductHandler) unifiedOrder(r *NativeProductRequest) (prepayId string, err error) {
+	req, err := h.handler(r)
+	if err != nil {
+		return
+	}
+	if req == nil {
+		return "", errNativeNilOrder
+	}
+	req.TradeType = "NATIVE"
+	req.ProductId = r.ProductId
+	req.OpenId = r.OpenId
+
+	// 服务商模式下在回调的子商户下单, 和回复里的 sub_mch_id 保持一致
+	resp, err := h.clt.WithSubMerchant(r.SubAppId, r.SubMchId).UnifiedOrder(req)
+	if err != nil {
+		return
+	}
+	return resp.PrepayId, nil
+}
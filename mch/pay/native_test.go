@@ -0,0 +1,72 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package pay
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/c77cc/util"
+	"github.com/c77cc/wechat/mch"
+)
+
+func TestNativeProductHandlerErrors(t *testing.T) {
+	const apiKey = "192006250b4c09247ec02edce69f6a2d"
+	clt := NewClient(mch.NewProxy(apiKey, nil), "wx2421b1c4370ec43b", "10000100")
+
+	tests := []struct {
+		name       string
+		handler    NativeProductHandlerFunc
+		errCodeDes string
+	}{
+		{
+			name:       "nil order",
+			handler:    func(*NativeProductRequest) (*UnifiedOrderRequest, error) { return nil, nil },
+			errCodeDes: nativeErrCodeDes,
+		},
+		{
+			name: "internal error",
+			handler: func(*NativeProductRequest) (*UnifiedOrderRequest, error) {
+				return nil, errors.New("dial tcp 10.0.0.1:3306: connection refused")
+			},
+			errCodeDes: nativeErrCodeDes,
+		},
+		{
+			name: "biz error",
+			handler: func(*NativeProductRequest) (*UnifiedOrderRequest, error) {
+				return nil, &mch.BizError{ResultCode: mch.ResultCodeFail, ErrCode: "SOLDOUT", ErrCodeDes: "商品已下架"}
+			},
+			errCodeDes: "商品已下架",
+		},
+	}
+	for _, test := range tests {
+		msg := map[string]string{
+			"appid":        "wx2421b1c4370ec43b",
+			"mch_id":       "10000100",
+			"openid":       "oUpF8uMuAJO_M2pxb1Q9zNjWeS6o",
+			"is_subscribe": "Y",
+			"product_id":   "88888",
+			"nonce_str":    "5K8264ILTKCH16CQ2502SI8ZNMTM67VS",
+		}
+		msg["sign"] = mch.Sign(msg, apiKey, nil)
+
+		w := httptest.NewRecorder()
+		NewNativeProductHandler(clt, test.handler).ServeMessage(w, &mch.Request{Msg: msg})
+
+		resp, err := util.ParseXMLToMap(w.Body)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if resp["result_code"] != mch.ResultCodeFail || resp["err_code_des"] != test.errCodeDes {
+			t.Errorf("%s: unexpected response: %v", test.name, resp)
+		}
+		if sign := mch.Sign(resp, apiKey, nil); resp["sign"] != sign {
+			t.Errorf("%s: sign mismatch, have %s, want %s", test.name, resp["sign"], sign)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package pay
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/c77cc/wechat/mch"
+	wechatutil "github.com/c77cc/wechat/util"
+)
+
+var (
+	errNotifyProcessing = errors.New("notification is being processed")
+	errUnsignedRequest  = errors.New("unsigned request")
+)
+
+const (
+	// 微信支付在收到 SUCCESS 应答之前会在 24 小时内多次重复通知
+	DefaultPayNotifyDedupTTL = 25 * time.Hour
+
+	// "处理中"标记的有效期, 防止进程在处理过程中崩溃后同一个通知一直被应答 FAIL
+	DefaultPayNotifyProcessingTTL = 5 * time.Minute
+)
+
+// 支付结果通知, 字段和订单查询的返回基本一致.
+//  ResultCode 为 FAIL 的时候表示支付失败, 也会交给 handler 处理.
+type PayNotify struct {
+	ResponseHeader
+	OrderInfo
+}
+
+func (n *PayNotify) decode(d *paramsDecoder) {
+	n.OrderInfo.decode(d)
+}
+
+// 解析支付结果通知, 和 decodeResponse 不同, result_code 为 FAIL 的时候不返回错误.
+func ParsePayNotify(msg map[string]string) (n *PayNotify, err error) {
+	d := &paramsDecoder{m: msg}
+	n = &PayNotify{}
+	n.ResponseHeader.decode(d)
+	n.decode(d)
+	if d.err != nil {
+		return nil, d.err
+	}
+	return
+}
+
+// 支付结果通知的处理函数, 返回 nil 则应答 SUCCESS, 否则应答 FAIL, 微信支付会稍后重新通知.
+type PayNotifyHandlerFunc func(n *PayNotify, r *mch.Request) error
+
+var _ mch.MessageHandler = (*PayNotifyHandler)(nil)
+
+// PayNotifyHandler 把支付结果通知解析为 *PayNotify 交给 handler 处理, 并根据处理结果应答微信支付.
+//  如果设置了 store, 同一个 transaction_id 在 ttl 时间内只会交给 handler 成功处理一次:
+//  1. 处理过程中收到的重复通知应答 FAIL, 让微信支付稍后重新通知;
+//  2. 只有 handler 成功后才记录为已处理, 之后收到的重复通知直接应答 SUCCESS.
+//
+//  handler := pay.NewPayNotifyHandler(func(n *pay.PayNotify, r *mch.Request) error {
+//      // 处理订单 n.OutTradeNo
+//      return nil
+//  }, util.NewMemoryDedupStore(10000), 0)
+//  messageServer := mch.NewDefaultMessageServer(appId, mchId, apiKey, handler)
+type PayNotifyHandler struct {
+	handler PayNotifyHandlerFunc
+	store   wechatutil.TwoPhaseDedupStore
+	ttl     time.Duration
+}
+
+// 创建一个新的 PayNotifyHandler.
+//  store == nil 表示不去重; 如果 ttl <= 0 则使用 DefaultPayNotifyDedupTTL.
+func NewPayNotifyHandler(handler PayNotifyHandlerFunc, store wechatutil.TwoPhaseDedupStore, ttl time.Duration) *PayNotifyHandler {
+	if handler == nil {
+		panic("nil PayNotifyHandlerFunc")
+	}
+	if ttl <= 0 {
+		ttl = DefaultPayNotifyDedupTTL
+	}
+	return &PayNotifyHandler{
+		handler: handler,
+		store:   store,
+		ttl:     ttl,
+	}
+}
+
+func (h *PayNotifyHandler) ServeMessage(w http.ResponseWriter, r *mch.Request) {
+	// return_code 为 FAIL 的是通信失败的通知, 没有业务数据, 也没有签名
+	if returnCode := r.Msg["return_code"]; returnCode != mch.ReturnCodeSuccess {
+		mch.LogInfoln("[WECHAT_PAY_NOTIFY] return_code:", returnCode, "return_msg:", r.Msg["return_msg"])
+		WriteNotifyResponse(w, nil)
+		return
+	}
+
+	// 支付结果通知一定有签名; 没有签名的只可能是退款结果通知或者伪造的请求
+	if r.ReqInfoXML != nil || r.Msg["sign"] == "" {
+		mch.LogInfoln("[WECHAT_PAY_NOTIFY] error:", errUnsignedRequest)
+		WriteNotifyResponse(w, errUnsignedRequest)
+		return
+	}
+
+	n, err := ParsePayNotify(r.Msg)
+	if err != nil {
+		mch.LogInfoln("[WECHAT_PAY_NOTIFY] parse error:", err)
+		WriteNotifyResponse(w, err)
+		return
+	}
+
+	if h.store == nil || n.TransactionId == "" {
+		WriteNotifyResponse(w, h.handler(n, r))
+		return
+	}
+
+	doneKey := "mch/" + n.MchId + "/" + n.TransactionId
+	processingKey := doneKey + "/processing"
+
+	if done, err := h.store.Contains(doneKey); err != nil {
+		// 存储出错的时候宁可重复处理也不能丢通知
+		mch.LogInfoln("[WECHAT_PAY_NOTIFY] store error:", err)
+		WriteNotifyResponse(w, h.handler(n, r))
+		return
+	} else if done {
+		WriteNotifyResponse(w, nil)
+		return
+	}
+
+	added, err := h.store.Add(processingKey, DefaultPayNotifyProcessingTTL)
+	if err != nil {
+		mch.LogInfoln("[WECHAT_PAY_NOTIFY] store error:", err)
+		WriteNotifyResponse(w, h.handler(n, r))
+		return
+	}
+	if !added {
+		WriteNotifyResponse(w, errNotifyProcessing)
+		return
+	}
+	defer func() {
+		if err := h.store.Remove(processingKey); err != nil {
+			mch.LogInfoln("[WECHAT_PAY_NOTIFY] store error:", err)
+		}
+	}()
+
+	// 拿到处理中标记之前, 另一个请求可能刚刚处理完成
+	if done, err := h.store.Contains(doneKey); err == nil && done {
+		WriteNotifyResponse(w, nil)
+		return
+	}
+
+	if err = h.handler(n, r); err == nil {
+		if _, err := h.store.Add(doneKey, h.ttl); err != nil {
+			mch.LogInfoln("[WECHAT_PAY_NOTIFY] store error:", err)
+		}
+	}
+	WriteNotifyResponse(w, err)
+}
+
+// 应答微信支付的通知, err == nil 时应答 SUCCESS, 否则应答 FAIL 并把 err 作为 return_msg.
+func WriteNotifyResponse(w http.ResponseWriter, err error) error {
+	resp := mch.Error{
+		ReturnCode: mch.ReturnCodeSuccess,
+		ReturnMsg:  "OK",
+	}
+	if err != nil {
+		resp.ReturnCode = mch.ReturnCodeFail
+		resp.ReturnMsg = err.Error()
+	}
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	return xml.NewEncoder(w).Encode(&resp)
+}
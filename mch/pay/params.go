@@ -0,0 +1,142 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package pay
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/c77cc/wechat/mch"
+)
+
+// 微信支付的时间格式, 北京时间
+const TimeLayout = "20060102150405"
+
+var beijingLocation = time.FixedZone("Asia/Shanghai", 8*60*60)
+
+// 把 t 格式化为微信支付的时间格式.
+func FormatTime(t time.Time) string {
+	return t.In(beijingLocation).Format(TimeLayout)
+}
+
+// 解析微信支付的时间格式.
+func ParseTime(value string) (time.Time, error) {
+	return time.ParseInLocation(TimeLayout, value, beijingLocation)
+}
+
+func setString(m map[string]string, key, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
+
+func setInt64(m map[string]string, key string, value int64) {
+	if value != 0 {
+		m[key] = strconv.FormatInt(value, 10)
+	}
+}
+
+func setTime(m map[string]string, key string, value time.Time) {
+	if !value.IsZero() {
+		m[key] = FormatTime(value)
+	}
+}
+
+// 从 map 中解析字段, 记录第一个错误.
+type paramsDecoder struct {
+	m   map[string]string
+	err error
+}
+
+func (d *paramsDecoder) String(key string) string {
+	return d.m[key]
+}
+
+func (d *paramsDecoder) Int64(key string) int64 {
+	value := d.m[key]
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil && d.err == nil {
+		d.err = fmt.Errorf("invalid %s: %q", key, value)
+	}
+	return n
+}
+
+func (d *paramsDecoder) Int(key string) int {
+	return int(d.Int64(key))
+}
+
+func (d *paramsDecoder) Bool(key string) bool {
+	return d.m[key] == "Y"
+}
+
+func (d *paramsDecoder) Time(key string) time.Time {
+	value := d.m[key]
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := ParseTime(value)
+	if err != nil && d.err == nil {
+		d.err = fmt.Errorf("invalid %s: %q", key, value)
+	}
+	return t
+}
+
+type response interface {
+	decode(d *paramsDecoder)
+	header() *ResponseHeader
+}
+
+// 解析 m 到 resp, 业务结果不为 SUCCESS 的时候返回 *mch.BizError.
+func decodeResponse(m map[string]string, resp response) (err error) {
+	d := &paramsDecoder{m: m}
+	resp.header().decode(d)
+	resp.decode(d)
+	if d.err != nil {
+		return d.err
+	}
+
+	if hdr := resp.header(); hdr.ResultCode != mch.ResultCodeSuccess {
+		return &mch.BizError{
+			ResultCode: hdr.ResultCode,
+			ErrCode:    hdr.ErrCode,
+			ErrCodeDes: hdr.ErrCodeDes,
+		}
+	}
+	return
+}
+
+// 响应的公共字段.
+type ResponseHeader struct {
+	AppId      string // 公众账号ID
+	MchId      string // 商户号
+	SubAppId   string // 子商户公众账号ID, 服务商模式
+	SubMchId   string // 子商户号, 服务商模式
+	DeviceInfo string // 设备号
+	NonceStr   string // 随机字符串
+	ResultCode string // 业务结果, SUCCESS/FAIL
+	ErrCode    string // 错误代码
+	ErrCodeDes string // 错误代码描述
+}
+
+func (hdr *ResponseHeader) header() *ResponseHeader {
+	return hdr
+}
+
+func (hdr *ResponseHeader) decode(d *paramsDecoder) {
+	hdr.AppId = d.String("appid")
+	hdr.MchId = d.String("mch_id")
+	hdr.SubAppId = d.String("sub_appid")
+	hdr.SubMchId = d.String("sub_mch_id")
+	hdr.DeviceInfo = d.String("device_info")
+	hdr.NonceStr = d.String("nonce_str")
+	hdr.ResultCode = d.String("result_code")
+	hdr.ErrCode = d.String("err_code")
+	hdr.ErrCodeDes = d.String("err_code_des")
+}
@@ -0,0 +1,98 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package pay
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// 等待确认支付结果的订单.
+type PendingOrder struct {
+	OutTradeNo string    // 商户订单号
+	CreateTime time.Time // 下单时间, 用于判断订单是否过期
+	Attempts   int       // 已经查询的次数
+	NextCheck  time.Time // 下一次查询的时间
+	TradeState string    // 上一次查询到的交易状态
+}
+
+// 等待确认的订单的存储接口, 见 Reconciler.
+//  多个进程共享同一个 PendingOrderStore 的时候, 需要存储自己保证同一个订单不会同时被多个进程处理.
+type PendingOrderStore interface {
+	// 添加订单, 订单已经存在则覆盖.
+	Add(order *PendingOrder) error
+	// 返回 NextCheck 不晚于 now 的订单, 最多 limit 个.
+	Due(now time.Time, limit int) ([]*PendingOrder, error)
+	// 更新订单的 Attempts, NextCheck 和 TradeState.
+	Update(order *PendingOrder) error
+	// 删除订单, 订单不存在也返回 nil.
+	Remove(outTradeNo string) error
+}
+
+var _ PendingOrderStore = (*MemoryPendingOrderStore)(nil)
+
+// PendingOrderStore 的内存实现, 只适用于单进程.
+type MemoryPendingOrderStore struct {
+	mutex  sync.Mutex
+	orders map[string]PendingOrder
+}
+
+func NewMemoryPendingOrderStore() *MemoryPendingOrderStore {
+	return &MemoryPendingOrderStore{
+		orders: make(map[string]PendingOrder),
+	}
+}
+
+func (store *MemoryPendingOrderStore) Add(order *PendingOrder) error {
+	store.mutex.Lock()
+	store.orders[order.OutTradeNo] = *order
+	store.mutex.Unlock()
+	return nil
+}
+
+func (store *MemoryPendingOrderStore) Due(now time.Time, limit int) (orders []*PendingOrder, err error) {
+	store.mutex.Lock()
+	for _, order := range store.orders {
+		if !order.NextCheck.After(now) {
+			order := order
+			orders = append(orders, &order)
+		}
+	}
+	store.mutex.Unlock()
+
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].NextCheck.Before(orders[j].NextCheck)
+	})
+	if limit > 0 && len(orders) > limit {
+		orders = orders[:limit]
+	}
+	return
+}
+
+func (store *MemoryPendingOrderStore) Update(order *PendingOrder) error {
+	store.mutex.Lock()
+	if _, ok := store.orders[order.OutTradeNo]; ok {
+		store.orders[order.OutTradeNo] = *order
+	}
+	store.mutex.Unlock()
+	return nil
+}
+
+func (store *MemoryPendingOrderStore) Remove(outTradeNo string) error {
+	store.mutex.Lock()
+	delete(store.orders, outTradeNo)
+	store.mutex.Unlock()
+	return nil
+}
+
+// 当前等待确认的订单数量.
+func (store *MemoryPendingOrderStore) Len() int {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return len(store.orders)
+}
@@ -0,0 +1,284 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package pay
+
+import (
+	"context"
+	"time"
+
+	"github.com/c77cc/wechat/mch"
+	wechatutil "github.com/c77cc/wechat/util"
+)
+
+// 交易状态
+const (
+	TradeStateSuccess    = "SUCCESS"    // 支付成功
+	TradeStateRefund     = "REFUND"     // 转入退款
+	TradeStateNotPay     = "NOTPAY"     // 未支付
+	TradeStateClosed     = "CLOSED"     // 已关闭
+	TradeStateRevoked    = "REVOKED"    // 已撤销(付款码支付)
+	TradeStateUserPaying = "USERPAYING" // 用户支付中(付款码支付)
+	TradeStatePayError   = "PAYERROR"   // 支付失败
+)
+
+const (
+	DefaultReconcileInterval  = time.Minute      // 默认的扫描待确认订单的间隔
+	DefaultReconcileBaseDelay = time.Minute      // 默认的第一次重新查询的间隔
+	DefaultReconcileMaxDelay  = 30 * time.Minute // 默认的重新查询的最大间隔
+	DefaultReconcileExpiry    = 2 * time.Hour    // 默认的订单过期时间, 和统一下单默认的 time_expire 一致
+	DefaultReconcileGiveUp    = time.Hour        // 默认的订单过期后关闭订单失败的重试时间
+	DefaultReconcileBatchSize = 100              // 默认的每次扫描最多处理的订单数量
+)
+
+// 订单状态的变化.
+type OrderTransition struct {
+	OutTradeNo string
+	TradeState string              // 新的交易状态, 见 TradeStateXXX
+	Final      bool                // 是否为最终状态, 最终状态的订单不再跟踪
+	ClosedByUs bool                // 订单过期后由 Reconciler 调用 CloseOrder 关闭
+	Order      *OrderQueryResponse // 查询订单的结果, 关闭订单时为最后一次查询的结果, 可能为 nil
+	Err        error               // 订单过期后没能确认交易状态, 放弃跟踪的原因; 这时 TradeState 为上一次查询到的交易状态
+}
+
+type OrderTransitionHandlerFunc func(t *OrderTransition)
+
+// 跟踪没有收到支付结果通知的订单: 按照退避策略查询订单, 状态变化的时候通知 Handler, 订单过期后关闭订单.
+//
+//  reconciler := &pay.Reconciler{
+//      Client:  client,
+//      Store:   pay.NewMemoryPendingOrderStore(),
+//      Handler: func(t *pay.OrderTransition) { ... },
+//  }
+//  go reconciler.Run(ctx)
+//
+//  resp, err := client.UnifiedOrder(req)
+//  reconciler.Track(req.OutTradeNo, time.Now())
+type Reconciler struct {
+	Client  *Client
+	Store   PendingOrderStore
+	Handler OrderTransitionHandlerFunc
+
+	Interval  time.Duration // 扫描待确认订单的间隔, 为 0 时使用 DefaultReconcileInterval
+	BaseDelay time.Duration // 第一次重新查询的间隔, 之后指数退避, 为 0 时使用 DefaultReconcileBaseDelay
+	MaxDelay  time.Duration // 重新查询的最大间隔, 为 0 时使用 DefaultReconcileMaxDelay
+	Expiry    time.Duration // 订单过期时间, 过期后关闭订单, 为 0 时使用 DefaultReconcileExpiry
+	GiveUp    time.Duration // 订单过期后关闭订单失败的重试时间, 超过后放弃跟踪, 为 0 时使用 DefaultReconcileGiveUp
+	BatchSize int           // 每次扫描最多处理的订单数量, 为 0 时使用 DefaultReconcileBatchSize
+}
+
+// 开始跟踪订单, createTime 为下单时间.
+func (r *Reconciler) Track(outTradeNo string, createTime time.Time) error {
+	return r.Store.Add(&PendingOrder{
+		OutTradeNo: outTradeNo,
+		CreateTime: createTime,
+		NextCheck:  createTime.Add(r.backoff().Backoff(1)),
+	})
+}
+
+// 停止跟踪订单, 一般在收到支付结果通知后调用.
+func (r *Reconciler) Untrack(outTradeNo string) error {
+	return r.Store.Remove(outTradeNo)
+}
+
+// 每隔 Interval 调用一次 RunOnce, 直到 ctx 被取消, 返回 ctx.Err().
+func (r *Reconciler) Run(ctx context.Context) error {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = DefaultReconcileInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			mch.LogInfoln("[WECHAT_RECONCILE] error:", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// 处理一批到了查询时间的订单.
+func (r *Reconciler) RunOnce(ctx context.Context) error {
+	batchSize := r.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultReconcileBatchSize
+	}
+	orders, err := r.Store.Due(time.Now(), batchSize)
+	if err != nil {
+		return err
+	}
+
+	clt := r.Client.WithContext(ctx)
+	for _, order := range orders {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if err = r.check(ctx, clt, order); err != nil {
+			mch.LogInfoln("[WECHAT_RECONCILE] out_trade_no:", order.OutTradeNo, "error:", err)
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) check(ctx context.Context, clt *Client, order *PendingOrder) (err error) {
+	resp, queryErr := clt.OrderQuery(&OrderQueryRequest{OutTradeNo: order.OutTradeNo})
+	if queryErr == nil {
+		var final bool
+		if final, err = r.transit(order, resp); final || err != nil {
+			return
+		}
+	} else {
+		if ctx.Err() != nil {
+			// 取消导致的失败不能说明订单的状态, 保留订单等下一次处理
+			return queryErr
+		}
+		resp = nil
+	}
+
+	age := time.Since(order.CreateTime)
+	if age < r.expiry() {
+		// 查询失败或者订单还没有支付, 稍后重试
+		if err = r.reschedule(order); err != nil {
+			return
+		}
+		return queryErr
+	}
+
+	// 订单过期, 关闭订单
+	if bizErr, ok := queryErr.(*mch.BizError); ok && bizErr.ErrCode == "ORDERNOTEXIST" {
+		// 订单不存在, 可能是下单失败了, 不再跟踪
+		return r.drop(order, queryErr)
+	}
+	// 查询失败的时候也尝试关闭订单, 已经支付的订单会返回 ORDERPAID
+	if _, err = clt.CloseOrder(&CloseOrderRequest{OutTradeNo: order.OutTradeNo}); err != nil {
+		if bizErr, ok := err.(*mch.BizError); ok && bizErr.ErrCode == "ORDERPAID" {
+			// 订单已经支付, 不管过期多久都不能放弃, 马上重新查询确认
+			return r.requery(clt, order)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if age >= r.expiry()+r.giveUp() {
+			return r.drop(order, err)
+		}
+		if rerr := r.reschedule(order); rerr != nil {
+			return rerr
+		}
+		return
+	}
+	if err = r.Store.Remove(order.OutTradeNo); err != nil {
+		return
+	}
+	r.emit(&OrderTransition{
+		OutTradeNo: order.OutTradeNo,
+		TradeState: TradeStateClosed,
+		Final:      true,
+		ClosedByUs: true,
+		Order:      resp,
+	})
+	return nil
+}
+
+// 处理查询订单的结果, 状态变化的时候通知 Handler; final 为 true 表示订单到了最终状态, 已经不再跟踪.
+func (r *Reconciler) transit(order *PendingOrder, resp *OrderQueryResponse) (final bool, err error) {
+	switch resp.TradeState {
+	case TradeStateSuccess, TradeStateRefund, TradeStateClosed, TradeStateRevoked, TradeStatePayError:
+		if err = r.Store.Remove(order.OutTradeNo); err != nil {
+			return
+		}
+		r.emit(&OrderTransition{
+			OutTradeNo: order.OutTradeNo,
+			TradeState: resp.TradeState,
+			Final:      true,
+			Order:      resp,
+		})
+		return true, nil
+	}
+	if resp.TradeState != order.TradeState {
+		order.TradeState = resp.TradeState
+		r.emit(&OrderTransition{
+			OutTradeNo: order.OutTradeNo,
+			TradeState: resp.TradeState,
+			Order:      resp,
+		})
+	}
+	return false, nil
+}
+
+// 关闭订单返回 ORDERPAID 后重新查询订单, 没有得到最终状态的话等下一次扫描马上再查询.
+func (r *Reconciler) requery(clt *Client, order *PendingOrder) (err error) {
+	resp, err := clt.OrderQuery(&OrderQueryRequest{OutTradeNo: order.OutTradeNo})
+	if err == nil {
+		var final bool
+		if final, err = r.transit(order, resp); final || err != nil {
+			return
+		}
+	}
+	order.NextCheck = time.Now()
+	if uerr := r.Store.Update(order); uerr != nil {
+		return uerr
+	}
+	return
+}
+
+// 放弃跟踪过期的订单, 通知 Handler.
+func (r *Reconciler) drop(order *PendingOrder, reason error) (err error) {
+	if err = r.Store.Remove(order.OutTradeNo); err != nil {
+		return
+	}
+	mch.LogInfoln("[WECHAT_RECONCILE] out_trade_no:", order.OutTradeNo, "dropped:", reason)
+	r.emit(&OrderTransition{
+		OutTradeNo: order.OutTradeNo,
+		TradeState: order.TradeState,
+		Final:      true,
+		Err:        reason,
+	})
+	return nil
+}
+
+func (r *Reconciler) reschedule(order *PendingOrder) error {
+	order.Attempts++
+	order.NextCheck = time.Now().Add(r.backoff().Backoff(order.Attempts + 1))
+	return r.Store.Update(order)
+}
+
+func (r *Reconciler) emit(t *OrderTransition) {
+	if r.Handler != nil {
+		r.Handler(t)
+	}
+}
+
+func (r *Reconciler) backoff() *wechatutil.RetryPolicy {
+	policy := &wechatutil.RetryPolicy{
+		BaseDelay: r.BaseDelay,
+		MaxDelay:  r.MaxDelay,
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = DefaultReconcileBaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = DefaultReconcileMaxDelay
+	}
+	return policy
+}
+
+func (r *Reconciler) expiry() time.Duration {
+	if r.Expiry > 0 {
+		return r.Expiry
+	}
+	return DefaultReconcileExpiry
+}
+
+func (r *Reconciler) giveUp() time.Duration {
+	if r.GiveUp > 0 {
+		return r.GiveUp
+	}
+	return DefaultReconcileGiveUp
+}
@@ -0,0 +1,188 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package pay
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/c77cc/wechat/mch"
+	"github.com/c77cc/wechat/mch/mchtest"
+)
+
+func TestReconciler(t *testing.T) {
+	const (
+		appId      = "wx2421b1c4370ec43b"
+		mchId      = "10000100"
+		apiKey     = "192006250b4c09247ec02edce69f6a2d"
+		outTradeNo = "1217752501201407033233368018"
+	)
+
+	tests := []struct {
+		name       string
+		age        time.Duration // 订单创建了多久
+		setup      func(srv *mchtest.Server, clt *Client, cancel context.CancelFunc) error
+		want       []string // 每次状态变化为 TradeState/Final/ClosedByUs/Err != nil
+		pending    int      // RunOnce 之后还在跟踪的订单数量
+		tradeState string   // RunOnce 之后测试服务器上的订单状态
+	}{
+		{
+			name: "success",
+			age:  time.Minute * 5,
+			setup: func(srv *mchtest.Server, clt *Client, cancel context.CancelFunc) error {
+				if err := unifiedOrder(clt, outTradeNo); err != nil {
+					return err
+				}
+				srv.Pay(outTradeNo)
+				return nil
+			},
+			want:       []string{"SUCCESS/true/false/false"},
+			tradeState: mchtest.TradeStateSuccess,
+		},
+		{
+			name: "notpay",
+			age:  time.Minute * 5,
+			setup: func(srv *mchtest.Server, clt *Client, cancel context.CancelFunc) error {
+				return unifiedOrder(clt, outTradeNo)
+			},
+			want:       []string{"NOTPAY/false/false/false"},
+			pending:    1,
+			tradeState: mchtest.TradeStateNotPay,
+		},
+		{
+			name: "notpay then close",
+			age:  time.Hour * 3,
+			setup: func(srv *mchtest.Server, clt *Client, cancel context.CancelFunc) error {
+				return unifiedOrder(clt, outTradeNo)
+			},
+			want:       []string{"NOTPAY/false/false/false", "CLOSED/true/true/false"},
+			tradeState: mchtest.TradeStateClosed,
+		},
+		{
+			name: "order not exist",
+			age:  time.Hour * 3,
+			setup: func(srv *mchtest.Server, clt *Client, cancel context.CancelFunc) error {
+				return nil
+			},
+			want: []string{"/true/false/true"},
+		},
+		{
+			// 过期超过 GiveUp 后关闭订单返回 ORDERPAID, 不能放弃跟踪, 要重新查询得到 SUCCESS
+			name: "orderpaid after give up",
+			age:  time.Hour * 4,
+			setup: func(srv *mchtest.Server, clt *Client, cancel context.CancelFunc) error {
+				if err := unifiedOrder(clt, outTradeNo); err != nil {
+					return err
+				}
+				srv.Pay(outTradeNo)
+				srv.InjectError("SYSTEMERROR") // orderquery
+				return nil
+			},
+			want:       []string{"SUCCESS/true/false/false"},
+			tradeState: mchtest.TradeStateSuccess,
+		},
+		{
+			// 查询失败的时候也关闭订单
+			name: "query error past expiry",
+			age:  time.Hour * 3,
+			setup: func(srv *mchtest.Server, clt *Client, cancel context.CancelFunc) error {
+				if err := unifiedOrder(clt, outTradeNo); err != nil {
+					return err
+				}
+				srv.InjectError("SYSTEMERROR") // orderquery
+				return nil
+			},
+			want:       []string{"CLOSED/true/true/false"},
+			tradeState: mchtest.TradeStateClosed,
+		},
+		{
+			name: "query and close error past give up",
+			age:  time.Hour * 4,
+			setup: func(srv *mchtest.Server, clt *Client, cancel context.CancelFunc) error {
+				if err := unifiedOrder(clt, outTradeNo); err != nil {
+					return err
+				}
+				srv.InjectError("SYSTEMERROR") // orderquery
+				srv.InjectError("SYSTEMERROR") // closeorder
+				return nil
+			},
+			want:       []string{"/true/false/true"},
+			tradeState: mchtest.TradeStateNotPay,
+		},
+		{
+			// 取消导致的失败不能放弃跟踪
+			name: "canceled past give up",
+			age:  time.Hour * 4,
+			setup: func(srv *mchtest.Server, clt *Client, cancel context.CancelFunc) error {
+				if err := unifiedOrder(clt, outTradeNo); err != nil {
+					return err
+				}
+				srv.HandleFunc("/pay/orderquery", func(req map[string]string) map[string]string {
+					cancel()
+					return map[string]string{"result_code": mch.ResultCodeFail, "err_code": "SYSTEMERROR"}
+				})
+				return nil
+			},
+			pending:    1,
+			tradeState: mchtest.TradeStateNotPay,
+		},
+	}
+	for _, test := range tests {
+		srv := mchtest.NewServer(appId, mchId, apiKey)
+		proxy := mch.NewProxy(apiKey, nil)
+		proxy.SetEndpoint(srv.Endpoint())
+		clt := NewClient(proxy, appId, mchId)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		if err := test.setup(srv, clt, cancel); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			srv.Close()
+			cancel()
+			continue
+		}
+
+		var have []string
+		store := NewMemoryPendingOrderStore()
+		reconciler := &Reconciler{
+			Client: clt,
+			Store:  store,
+			Handler: func(t *OrderTransition) {
+				have = append(have, fmt.Sprintf("%s/%v/%v/%v", t.TradeState, t.Final, t.ClosedByUs, t.Err != nil))
+			},
+		}
+		if err := reconciler.Track(outTradeNo, time.Now().Add(-test.age)); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		reconciler.RunOnce(ctx)
+
+		if fmt.Sprint(have) != fmt.Sprint(test.want) {
+			t.Errorf("%s: got transitions %v, want %v", test.name, have, test.want)
+		}
+		if n := store.Len(); n != test.pending {
+			t.Errorf("%s: %d pending orders, want %d", test.name, n, test.pending)
+		}
+		if order, _ := srv.Order(outTradeNo); order.TradeState != test.tradeState {
+			t.Errorf("%s: got trade_state %q, want %q", test.name, order.TradeState, test.tradeState)
+		}
+		srv.Close()
+		cancel()
+	}
+}
+
+func unifiedOrder(clt *Client, outTradeNo string) error {
+	_, err := clt.UnifiedOrder(&UnifiedOrderRequest{
+		Body:           "腾讯充值中心-QQ会员充值",
+		OutTradeNo:     outTradeNo,
+		TotalFee:       1,
+		SpbillCreateIP: "8.8.8.8",
+		NotifyURL:      "http://wxpay.weixin.qq.com/pub_v2/pay/notify.v2.php",
+		TradeType:      "NATIVE",
+		ProductId:      "12235413214070356458058",
+	})
+	return err
+}
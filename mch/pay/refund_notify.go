@@ -0,0 +1,114 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package pay
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/c77cc/util"
+	"github.com/c77cc/wechat/mch"
+)
+
+// 退款结果通知, 金额的单位为分.
+type RefundNotify struct {
+	AppId    string // 公众账号ID
+	MchId    string // 商户号
+	SubAppId string // 子商户公众账号ID, 服务商模式
+	SubMchId string // 子商户号, 服务商模式
+	NonceStr string // 随机字符串
+
+	// 以下字段由 req_info 解密得到
+	TransactionId       string    // 微信订单号
+	OutTradeNo          string    // 商户订单号
+	RefundId            string    // 微信退款单号
+	OutRefundNo         string    // 商户退款单号
+	TotalFee            int64     // 订单金额
+	SettlementTotalFee  int64     // 应结订单金额
+	RefundFee           int64     // 申请退款金额
+	SettlementRefundFee int64     // 退款金额
+	RefundStatus        string    // 退款状态, SUCCESS, CHANGE, REFUNDCLOSE
+	SuccessTime         time.Time // 退款成功时间
+	RefundRecvAccout    string    // 退款入账账户
+	RefundAccount       string    // 退款资金来源
+	RefundRequestSource string    // 退款发起来源
+}
+
+// 解析退款结果通知, reqInfoXML 是解密后的 req_info, 见 mch.Request.ReqInfoXML.
+func ParseRefundNotify(msg map[string]string, reqInfoXML []byte) (n *RefundNotify, err error) {
+	if reqInfoXML == nil {
+		return nil, errors.New("no req_info")
+	}
+	reqInfo, err := util.ParseXMLToMap(bytes.NewReader(reqInfoXML))
+	if err != nil {
+		return nil, fmt.Errorf("invalid req_info: %s", err)
+	}
+
+	d := &paramsDecoder{m: reqInfo}
+	n = &RefundNotify{
+		AppId:    msg["appid"],
+		MchId:    msg["mch_id"],
+		SubAppId: msg["sub_appid"],
+		SubMchId: msg["sub_mch_id"],
+		NonceStr: msg["nonce_str"],
+
+		TransactionId:       d.String("transaction_id"),
+		OutTradeNo:          d.String("out_trade_no"),
+		RefundId:            d.String("refund_id"),
+		OutRefundNo:         d.String("out_refund_no"),
+		TotalFee:            d.Int64("total_fee"),
+		SettlementTotalFee:  d.Int64("settlement_total_fee"),
+		RefundFee:           d.Int64("refund_fee"),
+		SettlementRefundFee: d.Int64("settlement_refund_fee"),
+		RefundStatus:        d.String("refund_status"),
+		RefundRecvAccout:    d.String("refund_recv_accout"),
+		RefundAccount:       d.String("refund_account"),
+		RefundRequestSource: d.String("refund_request_source"),
+	}
+	// 退款结果通知的时间格式和对账单一样
+	if value := d.String("success_time"); value != "" {
+		if n.SuccessTime, err = time.ParseInLocation(BillTimeLayout, value, beijingLocation); err != nil {
+			return nil, fmt.Errorf("invalid success_time: %q", value)
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return n, nil
+}
+
+// 退款结果通知的处理函数, 返回 nil 则应答 SUCCESS, 否则应答 FAIL, 微信支付会稍后重新通知.
+type RefundNotifyHandlerFunc func(n *RefundNotify, r *mch.Request) error
+
+var _ mch.MessageHandler = RefundNotifyHandlerFunc(nil)
+
+// 把退款结果通知解析为 *RefundNotify 交给 fn 处理, 并根据处理结果应答微信支付.
+//  req_info 已经由 mch.ServeHTTP 用 MessageServer 的 API密钥 解密.
+//
+//  handler := pay.RefundNotifyHandlerFunc(func(n *pay.RefundNotify, r *mch.Request) error {
+//      // 处理退款单 n.OutRefundNo
+//      return nil
+//  })
+//  messageServer := mch.NewDefaultMessageServer(appId, mchId, apiKey, handler)
+func (fn RefundNotifyHandlerFunc) ServeMessage(w http.ResponseWriter, r *mch.Request) {
+	// return_code 为 FAIL 的是通信失败的通知, 没有业务数据
+	if returnCode := r.Msg["return_code"]; returnCode != mch.ReturnCodeSuccess {
+		mch.LogInfoln("[WECHAT_REFUND_NOTIFY] return_code:", returnCode, "return_msg:", r.Msg["return_msg"])
+		WriteNotifyResponse(w, nil)
+		return
+	}
+
+	n, err := ParseRefundNotify(r.Msg, r.ReqInfoXML)
+	if err != nil {
+		mch.LogInfoln("[WECHAT_REFUND_NOTIFY] parse error:", err)
+		WriteNotifyResponse(w, err)
+		return
+	}
+	WriteNotifyResponse(w, fn(n, r))
+}
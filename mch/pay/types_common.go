@@ -0,0 +1,343 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package pay
+
+import (
+	"strconv"
+	"time"
+)
+
+// 统一下单的请求参数, 金额的单位为分.
+type UnifiedOrderRequest struct {
+	DeviceInfo     string    // 终端设备号
+	Body           string    // 商品描述
+	Detail         string    // 商品详情
+	Attach         string    // 附加数据
+	OutTradeNo     string    // 商户订单号
+	FeeType        string    // 货币类型, 默认 CNY
+	TotalFee       int64     // 总金额
+	SpbillCreateIP string    // 终端IP
+	TimeStart      time.Time // 交易起始时间
+	TimeExpire     time.Time // 交易结束时间
+	GoodsTag       string    // 商品标记
+	NotifyURL      string    // 通知地址
+	TradeType      string    // 交易类型, JSAPI, NATIVE, APP
+	ProductId      string    // 商品ID, trade_type=NATIVE 时必传
+	LimitPay       string    // 指定支付方式, no_credit
+	OpenId         string    // 用户标识, trade_type=JSAPI 时必传
+	SubOpenId      string    // 用户在子商户 sub_appid 下的标识, 服务商模式
+}
+
+func (req *UnifiedOrderRequest) toMap() map[string]string {
+	m := make(map[string]string, 24)
+	setString(m, "device_info", req.DeviceInfo)
+	setString(m, "body", req.Body)
+	setString(m, "detail", req.Detail)
+	setString(m, "attach", req.Attach)
+	setString(m, "out_trade_no", req.OutTradeNo)
+	setString(m, "fee_type", req.FeeType)
+	setInt64(m, "total_fee", req.TotalFee)
+	setString(m, "spbill_create_ip", req.SpbillCreateIP)
+	setTime(m, "time_start", req.TimeStart)
+	setTime(m, "time_expire", req.TimeExpire)
+	setString(m, "goods_tag", req.GoodsTag)
+	setString(m, "notify_url", req.NotifyURL)
+	setString(m, "trade_type", req.TradeType)
+	setString(m, "product_id", req.ProductId)
+	setString(m, "limit_pay", req.LimitPay)
+	setString(m, "openid", req.OpenId)
+	setString(m, "sub_openid", req.SubOpenId)
+	return m
+}
+
+type UnifiedOrderResponse struct {
+	ResponseHeader
+	TradeType string // 交易类型
+	PrepayId  string // 预支付交易会话标识
+	CodeURL   string // 二维码链接, trade_type=NATIVE 时返回
+}
+
+func (resp *UnifiedOrderResponse) decode(d *paramsDecoder) {
+	resp.TradeType = d.String("trade_type")
+	resp.PrepayId = d.String("prepay_id")
+	resp.CodeURL = d.String("code_url")
+}
+
+// 订单查询的请求参数, TransactionId 和 OutTradeNo 二选一, 优先 TransactionId.
+type OrderQueryRequest struct {
+	TransactionId string // 微信订单号
+	OutTradeNo    string // 商户订单号
+}
+
+func (req *OrderQueryRequest) toMap() map[string]string {
+	m := make(map[string]string, 8)
+	setString(m, "transaction_id", req.TransactionId)
+	setString(m, "out_trade_no", req.OutTradeNo)
+	return m
+}
+
+// 代金券或立减优惠
+type Coupon struct {
+	BatchId string // 代金券或立减优惠批次ID
+	Id      string // 代金券或立减优惠ID
+	Fee     int64  // 单个代金券或立减优惠支付金额
+}
+
+// 订单的支付信息, 订单查询和被扫支付共用.
+type OrderInfo struct {
+	OpenId         string    // 用户标识
+	IsSubscribe    bool      // 是否关注公众账号
+	SubOpenId      string    // 用户在子商户 sub_appid 下的标识, 服务商模式
+	SubIsSubscribe bool      // 是否关注子商户公众账号, 服务商模式
+	TradeType      string    // 交易类型
+	BankType       string    // 付款银行
+	TotalFee       int64     // 总金额
+	FeeType        string    // 货币种类
+	CashFee        int64     // 现金支付金额
+	CashFeeType    string    // 现金支付货币类型
+	CouponFee      int64     // 代金券或立减优惠金额
+	Coupons        []Coupon  // 代金券或立减优惠, 对应 coupon_batch_id_$n, coupon_id_$n, coupon_fee_$n
+	TransactionId  string    // 微信支付订单号
+	OutTradeNo     string    // 商户订单号
+	Attach         string    // 附加数据
+	TimeEnd        time.Time // 支付完成时间
+}
+
+func (info *OrderInfo) decode(d *paramsDecoder) {
+	info.OpenId = d.String("openid")
+	info.IsSubscribe = d.Bool("is_subscribe")
+	info.SubOpenId = d.String("sub_openid")
+	info.SubIsSubscribe = d.Bool("sub_is_subscribe")
+	info.TradeType = d.String("trade_type")
+	info.BankType = d.String("bank_type")
+	info.TotalFee = d.Int64("total_fee")
+	info.FeeType = d.String("fee_type")
+	info.CashFee = d.Int64("cash_fee")
+	info.CashFeeType = d.String("cash_fee_type")
+	info.CouponFee = d.Int64("coupon_fee")
+	info.TransactionId = d.String("transaction_id")
+	info.OutTradeNo = d.String("out_trade_no")
+	info.Attach = d.String("attach")
+	info.TimeEnd = d.Time("time_end")
+
+	// coupon_count 有的时候不返回, 以 coupon_id_$n 是否存在为准
+	info.Coupons = nil
+	for n := 0; ; n++ {
+		suffix := "_" + strconv.Itoa(n)
+		if _, ok := d.m["coupon_id"+suffix]; !ok {
+			break
+		}
+		info.Coupons = append(info.Coupons, Coupon{
+			BatchId: d.String("coupon_batch_id" + suffix),
+			Id:      d.String("coupon_id" + suffix),
+			Fee:     d.Int64("coupon_fee" + suffix),
+		})
+	}
+}
+
+type OrderQueryResponse struct {
+	ResponseHeader
+	OrderInfo
+	TradeState     string // 交易状态
+	TradeStateDesc string // 交易状态描述
+}
+
+func (resp *OrderQueryResponse) decode(d *paramsDecoder) {
+	resp.OrderInfo.decode(d)
+	resp.TradeState = d.String("trade_state")
+	resp.TradeStateDesc = d.String("trade_state_desc")
+}
+
+// 关闭订单的请求参数.
+type CloseOrderRequest struct {
+	OutTradeNo string // 商户订单号
+}
+
+func (req *CloseOrderRequest) toMap() map[string]string {
+	m := make(map[string]string, 8)
+	setString(m, "out_trade_no", req.OutTradeNo)
+	return m
+}
+
+type CloseOrderResponse struct {
+	ResponseHeader
+}
+
+func (resp *CloseOrderResponse) decode(d *paramsDecoder) {}
+
+// 申请退款的请求参数, TransactionId 和 OutTradeNo 二选一, 优先 TransactionId.
+//  OpUserId 为空时接口要求填商户号, 由 Client 自动填充.
+type RefundRequest struct {
+	DeviceInfo    string // 设备号
+	TransactionId string // 微信订单号
+	OutTradeNo    string // 商户订单号
+	OutRefundNo   string // 商户退款单号
+	TotalFee      int64  // 总金额
+	RefundFee     int64  // 退款金额
+	RefundFeeType string // 货币种类
+	OpUserId      string // 操作员
+}
+
+func (req *RefundRequest) toMap() map[string]string {
+	m := make(map[string]string, 16)
+	setString(m, "device_info", req.DeviceInfo)
+	setString(m, "transaction_id", req.TransactionId)
+	setString(m, "out_trade_no", req.OutTradeNo)
+	setString(m, "out_refund_no", req.OutRefundNo)
+	setInt64(m, "total_fee", req.TotalFee)
+	setInt64(m, "refund_fee", req.RefundFee)
+	setString(m, "refund_fee_type", req.RefundFeeType)
+	setString(m, "op_user_id", req.OpUserId)
+	return m
+}
+
+// 退款的代金券或立减优惠
+type RefundCoupon struct {
+	BatchId   string // 退款代金券批次ID
+	Id        string // 退款代金券ID
+	RefundFee int64  // 单个退款代金券支付金额
+}
+
+type RefundResponse struct {
+	ResponseHeader
+	TransactionId   string         // 微信订单号
+	OutTradeNo      string         // 商户订单号
+	OutRefundNo     string         // 商户退款单号
+	RefundId        string         // 微信退款单号
+	RefundChannel   string         // 退款渠道
+	RefundFee       int64          // 退款金额
+	TotalFee        int64          // 订单总金额
+	FeeType         string         // 订单金额货币种类
+	CashFee         int64          // 现金支付金额
+	CashRefundFee   int64          // 现金退款金额
+	CouponRefundFee int64          // 代金券或立减优惠退款金额
+	CouponRefunds   []RefundCoupon // 对应 coupon_refund_batch_id_$n, coupon_refund_id_$n, coupon_refund_fee_$n
+}
+
+func (resp *RefundResponse) decode(d *paramsDecoder) {
+	resp.TransactionId = d.String("transaction_id")
+	resp.OutTradeNo = d.String("out_trade_no")
+	resp.OutRefundNo = d.String("out_refund_no")
+	resp.RefundId = d.String("refund_id")
+	resp.RefundChannel = d.String("refund_channel")
+	resp.RefundFee = d.Int64("refund_fee")
+	resp.TotalFee = d.Int64("total_fee")
+	resp.FeeType = d.String("fee_type")
+	resp.CashFee = d.Int64("cash_fee")
+	resp.CashRefundFee = d.Int64("cash_refund_fee")
+	resp.CouponRefundFee = d.Int64("coupon_refund_fee")
+	resp.CouponRefunds = decodeRefundCoupons(d, "")
+}
+
+// 解析 coupon_refund_batch_id{prefix}_$n 这样的字段.
+func decodeRefundCoupons(d *paramsDecoder, prefix string) (coupons []RefundCoupon) {
+	for n := 0; ; n++ {
+		suffix := prefix + "_" + strconv.Itoa(n)
+		if _, ok := d.m["coupon_refund_id"+suffix]; !ok {
+			return
+		}
+		coupons = append(coupons, RefundCoupon{
+			BatchId:   d.String("coupon_refund_batch_id" + suffix),
+			Id:        d.String("coupon_refund_id" + suffix),
+			RefundFee: d.Int64("coupon_refund_fee" + suffix),
+		})
+	}
+}
+
+// 退款查询的请求参数, 四个参数必填一个, 优先级为 RefundId > OutRefundNo > TransactionId > OutTradeNo.
+type RefundQueryRequest struct {
+	DeviceInfo    string // 设备号
+	TransactionId string // 微信订单号
+	OutTradeNo    string // 商户订单号
+	OutRefundNo   string // 商户退款单号
+	RefundId      string // 微信退款单号
+	Offset        int64  // 偏移量, 订单的退款超过 10 笔的时候分页查询, 每页 10 笔
+}
+
+func (req *RefundQueryRequest) toMap() map[string]string {
+	m := make(map[string]string, 12)
+	setString(m, "device_info", req.DeviceInfo)
+	setString(m, "transaction_id", req.TransactionId)
+	setString(m, "out_trade_no", req.OutTradeNo)
+	setString(m, "out_refund_no", req.OutRefundNo)
+	setString(m, "refund_id", req.RefundId)
+	setInt64(m, "offset", req.Offset)
+	return m
+}
+
+// 退款查询返回的单笔退款
+type RefundInfo struct {
+	OutRefundNo      string         // 商户退款单号
+	RefundId         string         // 微信退款单号
+	RefundChannel    string         // 退款渠道
+	RefundFee        int64          // 退款金额
+	CouponRefundFee  int64          // 代金券或立减优惠退款金额
+	CouponRefunds    []RefundCoupon // 对应 coupon_refund_batch_id_$n_$m 等
+	RefundStatus     string         // 退款状态
+	RefundRecvAccout string         // 退款入账账户
+}
+
+type RefundQueryResponse struct {
+	ResponseHeader
+	TransactionId    string       // 微信订单号
+	OutTradeNo       string       // 商户订单号
+	TotalFee         int64        // 订单总金额
+	FeeType          string       // 订单金额货币种类
+	CashFee          int64        // 现金支付金额
+	TotalRefundCount int64        // 订单总共的退款笔数
+	Refunds          []RefundInfo // 对应 refund_count 和 out_refund_no_$n 等
+}
+
+func (resp *RefundQueryResponse) decode(d *paramsDecoder) {
+	resp.TransactionId = d.String("transaction_id")
+	resp.OutTradeNo = d.String("out_trade_no")
+	resp.TotalFee = d.Int64("total_fee")
+	resp.FeeType = d.String("fee_type")
+	resp.CashFee = d.Int64("cash_fee")
+	resp.TotalRefundCount = d.Int64("total_refund_count")
+
+	resp.Refunds = nil
+	refundCount := d.Int("refund_count")
+	for n := 0; n < refundCount; n++ {
+		suffix := "_" + strconv.Itoa(n)
+		resp.Refunds = append(resp.Refunds, RefundInfo{
+			OutRefundNo:      d.String("out_refund_no" + suffix),
+			RefundId:         d.String("refund_id" + suffix),
+			RefundChannel:    d.String("refund_channel" + suffix),
+			RefundFee:        d.Int64("refund_fee" + suffix),
+			CouponRefundFee:  d.Int64("coupon_refund_fee" + suffix),
+			CouponRefunds:    decodeRefundCoupons(d, suffix),
+			RefundStatus:     d.String("refund_status" + suffix),
+			RefundRecvAccout: d.String("refund_recv_accout" + suffix),
+		})
+	}
+}
+
+// 对账单类型
+const (
+	BillTypeAll     = "ALL"     // 当日所有订单信息
+	BillTypeSuccess = "SUCCESS" // 当日成功支付的订单
+	BillTypeRefund  = "REFUND"  // 当日退款订单
+)
+
+// 下载对账单的请求参数.
+type DownloadBillRequest struct {
+	DeviceInfo string    // 设备号
+	BillDate   time.Time // 对账单日期, 按北京时间取日期
+	BillType   string    // 账单类型, 默认为 BillTypeAll
+	TarType    string    // 压缩账单, 目前只支持 GZIP
+}
+
+func (req *DownloadBillRequest) toMap() map[string]string {
+	m := make(map[string]string, 10)
+	setString(m, "device_info", req.DeviceInfo)
+	if !req.BillDate.IsZero() {
+		m["bill_date"] = req.BillDate.In(beijingLocation).Format("20060102")
+	}
+	setString(m, "bill_type", req.BillType)
+	setString(m, "tar_type", req.TarType)
+	return m
+}
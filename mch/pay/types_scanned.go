@@ -0,0 +1,68 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package pay
+
+// 提交被扫支付的请求参数, 金额的单位为分.
+type MicroPayRequest struct {
+	DeviceInfo     string // 终端设备号
+	Body           string // 商品描述
+	Detail         string // 商品详情
+	Attach         string // 附加数据
+	OutTradeNo     string // 商户订单号
+	TotalFee       int64  // 总金额
+	FeeType        string // 货币类型, 默认 CNY
+	SpbillCreateIP string // 终端IP
+	GoodsTag       string // 商品标记
+	LimitPay       string // 指定支付方式, no_credit
+	AuthCode       string // 授权码
+}
+
+func (req *MicroPayRequest) toMap() map[string]string {
+	m := make(map[string]string, 16)
+	setString(m, "device_info", req.DeviceInfo)
+	setString(m, "body", req.Body)
+	setString(m, "detail", req.Detail)
+	setString(m, "attach", req.Attach)
+	setString(m, "out_trade_no", req.OutTradeNo)
+	setInt64(m, "total_fee", req.TotalFee)
+	setString(m, "fee_type", req.FeeType)
+	setString(m, "spbill_create_ip", req.SpbillCreateIP)
+	setString(m, "goods_tag", req.GoodsTag)
+	setString(m, "limit_pay", req.LimitPay)
+	setString(m, "auth_code", req.AuthCode)
+	return m
+}
+
+type MicroPayResponse struct {
+	ResponseHeader
+	OrderInfo
+}
+
+func (resp *MicroPayResponse) decode(d *paramsDecoder) {
+	resp.OrderInfo.decode(d)
+}
+
+// 撤销支付的请求参数, TransactionId 和 OutTradeNo 二选一, 优先 TransactionId.
+type ReverseRequest struct {
+	TransactionId string // 微信订单号
+	OutTradeNo    string // 商户订单号
+}
+
+func (req *ReverseRequest) toMap() map[string]string {
+	m := make(map[string]string, 8)
+	setString(m, "transaction_id", req.TransactionId)
+	setString(m, "out_trade_no", req.OutTradeNo)
+	return m
+}
+
+type ReverseResponse struct {
+	ResponseHeader
+	Recall bool // 是否需要继续调用撤销
+}
+
+func (resp *ReverseResponse) decode(d *paramsDecoder) {
+	resp.Recall = d.Bool("recall")
+}
@@ -0,0 +1,88 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mch
+
+import (
+	"strconv"
+	"time"
+
+	wechatutil "github.com/c77cc/wechat/util"
+)
+
+// 公众号 JSAPI 支付的参数, 用于 WeixinJSBridge.invoke("getBrandWCPayRequest", ...) 和 wx.chooseWXPay.
+//  NOTE: wx.chooseWXPay 的参数名是 timestamp(全小写), 签名的时候还是用 timeStamp.
+type JSAPIPayParams struct {
+	AppId     string `json:"appId"`
+	TimeStamp string `json:"timeStamp"`
+	NonceStr  string `json:"nonceStr"`
+	Package   string `json:"package"`
+	SignType  string `json:"signType"`
+	PaySign   string `json:"paySign"`
+}
+
+// 根据统一下单返回的 prepay_id 生成 JSAPI 支付的参数.
+//  signType 要和统一下单的签名类型一致, 为空时默认为 SignTypeMD5.
+func NewJSAPIPayParams(appId, prepayId, apiKey, signType string) (params *JSAPIPayParams, err error) {
+	if signType == "" {
+		signType = SignTypeMD5
+	}
+	params = &JSAPIPayParams{
+		AppId:     appId,
+		TimeStamp: strconv.FormatInt(time.Now().Unix(), 10),
+		NonceStr:  wechatutil.NonceStr(),
+		Package:   "prepay_id=" + prepayId,
+		SignType:  signType,
+	}
+
+	m := make(map[string]string, 5)
+	m["appId"] = params.AppId
+	m["timeStamp"] = params.TimeStamp
+	m["nonceStr"] = params.NonceStr
+	m["package"] = params.Package
+	m["signType"] = params.SignType
+
+	if params.PaySign, err = SignWithType(m, apiKey, signType); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// APP 支付的参数, 用于 APP 端 SDK 调起支付(PayReq).
+type APPPayParams struct {
+	AppId     string `json:"appid"`
+	PartnerId string `json:"partnerid"`
+	PrepayId  string `json:"prepayid"`
+	Package   string `json:"package"`
+	NonceStr  string `json:"noncestr"`
+	TimeStamp string `json:"timestamp"`
+	Sign      string `json:"sign"`
+}
+
+// 根据统一下单返回的 prepay_id 生成 APP 支付的参数.
+//  signType 要和统一下单的签名类型一致, 为空时默认为 SignTypeMD5.
+func NewAPPPayParams(appId, mchId, prepayId, apiKey, signType string) (params *APPPayParams, err error) {
+	params = &APPPayParams{
+		AppId:     appId,
+		PartnerId: mchId,
+		PrepayId:  prepayId,
+		Package:   "Sign=WXPay",
+		NonceStr:  wechatutil.NonceStr(),
+		TimeStamp: strconv.FormatInt(time.Now().Unix(), 10),
+	}
+
+	m := make(map[string]string, 6)
+	m["appid"] = params.AppId
+	m["partnerid"] = params.PartnerId
+	m["prepayid"] = params.PrepayId
+	m["package"] = params.Package
+	m["noncestr"] = params.NonceStr
+	m["timestamp"] = params.TimeStamp
+
+	if params.Sign, err = SignWithType(m, apiKey, signType); err != nil {
+		return nil, err
+	}
+	return
+}
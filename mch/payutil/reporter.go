@@ -0,0 +1,217 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package payutil
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	wechatjson "github.com/c77cc/wechat/json"
+	"github.com/c77cc/wechat/mch"
+	wechatutil "github.com/c77cc/wechat/util"
+)
+
+const (
+	DefaultReportQueueSize     = 1024             // 默认的上报队列的容量
+	DefaultReportBatchSize     = 20               // 默认的一次批量上报的最多条数
+	DefaultReportFlushInterval = 10 * time.Second // 默认的批量上报的间隔
+)
+
+var beijingLocation = time.FixedZone("Asia/Shanghai", 8*60*60)
+
+var _ mch.CallObserver = (*Reporter)(nil)
+
+// 自动测速上报, 实现了 mch.CallObserver.
+//  ObserveCall 只是把上报数据放到有界的队列里, 由后台的 goroutine 收集起来, 每隔 DefaultReportFlushInterval
+//  或者攒够 DefaultReportBatchSize 条的时候调用一次 Report 批量上报, 多条数据放在 trades 字段的 JSON 数组里;
+//  队列满了直接丢弃, 上报失败只记录日志, 永远不会阻塞或者影响业务请求.
+//
+//  reporter := payutil.NewReporter(mch.NewProxy(apiKey, nil), appId, mchId, serverIP, 0)
+//  defer reporter.Close()
+//  proxy.SetCallObserver(reporter)
+type Reporter struct {
+	proxy  *mch.Proxy // 用于上报的 Proxy, 不能设置 reporter 本身为 CallObserver
+	appId  string
+	mchId  string
+	userIP string
+
+	queue         chan map[string]string
+	batchSize     int
+	flushInterval time.Duration
+	dropped       int64 // 因为队列满了而丢弃的数量, 原子操作
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
+}
+
+// 创建并启动一个 Reporter.
+//  userIP 为发起接口调用的机器的 IP, 为空时使用请求参数里的 spbill_create_ip;
+//  如果 queueSize <= 0 则使用 DefaultReportQueueSize.
+func NewReporter(proxy *mch.Proxy, appId, mchId, userIP string, queueSize int) *Reporter {
+	return newReporter(proxy, appId, mchId, userIP, queueSize, DefaultReportBatchSize, DefaultReportFlushInterval)
+}
+
+func newReporter(proxy *mch.Proxy, appId, mchId, userIP string, queueSize, batchSize int, flushInterval time.Duration) *Reporter {
+	if proxy == nil {
+		panic("nil mch.Proxy")
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultReportQueueSize
+	}
+	reporter := &Reporter{
+		proxy:  proxy,
+		appId:  appId,
+		mchId:  mchId,
+		userIP: userIP,
+		queue:  make(chan map[string]string, queueSize),
+
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+
+		closed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go reporter.run()
+	return reporter
+}
+
+func (reporter *Reporter) ObserveCall(stat *mch.CallStat) {
+	if strings.HasSuffix(stat.URL, "/payitil/report") {
+		return
+	}
+	select {
+	case <-reporter.closed:
+		return
+	default:
+	}
+
+	req := make(map[string]string, 16)
+	req["interface_url"] = stat.URL
+	req["execute_time_"] = strconv.FormatInt(int64(stat.Duration/time.Millisecond), 10)
+	req["time"] = stat.StartTime.In(beijingLocation).Format("20060102150405")
+	if device := stat.Request["device_info"]; device != "" {
+		req["device_info"] = device
+	}
+	if outTradeNo := stat.Request["out_trade_no"]; outTradeNo != "" {
+		req["out_trade_no"] = outTradeNo
+	}
+	req["user_ip"] = reporter.userIP
+	if req["user_ip"] == "" {
+		req["user_ip"] = stat.Request["spbill_create_ip"]
+	}
+
+	switch err := stat.Err.(type) {
+	case nil:
+		req["return_code"] = mch.ReturnCodeSuccess
+		req["result_code"] = stat.Response["result_code"]
+	case *mch.Error:
+		req["return_code"] = err.ReturnCode
+		req["return_msg"] = err.ReturnMsg
+	default:
+		// 网络错误等没有返回数据的情况
+		req["return_code"] = mch.ReturnCodeFail
+		req["return_msg"] = err.Error()
+	}
+	if stat.Response != nil {
+		if errCode := stat.Response["err_code"]; errCode != "" {
+			req["result_code"] = stat.Response["result_code"]
+			req["err_code"] = errCode
+			req["err_code_des"] = stat.Response["err_code_des"]
+		}
+	}
+
+	select {
+	case reporter.queue <- req:
+	default:
+		atomic.AddInt64(&reporter.dropped, 1)
+	}
+}
+
+// 因为队列满了而丢弃的上报数量.
+func (reporter *Reporter) Dropped() int64 {
+	return atomic.LoadInt64(&reporter.dropped)
+}
+
+// 停止上报, 队列里和还没有上报的数据会在 Close 返回之前上报完.
+func (reporter *Reporter) Close() {
+	reporter.closeOnce.Do(func() {
+		close(reporter.closed)
+	})
+	<-reporter.done
+}
+
+func (reporter *Reporter) run() {
+	defer close(reporter.done)
+
+	ticker := time.NewTicker(reporter.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]map[string]string, 0, reporter.batchSize)
+	add := func(req map[string]string) {
+		batch = append(batch, req)
+		if len(batch) >= reporter.batchSize {
+			reporter.report(batch)
+			batch = batch[:0]
+		}
+	}
+	flush := func() {
+		if len(batch) > 0 {
+			reporter.report(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case req := <-reporter.queue:
+			add(req)
+		case <-ticker.C:
+			flush()
+		case <-reporter.closed:
+			for {
+				select {
+				case req := <-reporter.queue:
+					add(req)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// 上报一批数据, 只有一条的时候按照单条的格式上报, 多条的时候放在 trades 字段里.
+func (reporter *Reporter) report(batch []map[string]string) {
+	var req map[string]string
+	if len(batch) == 1 {
+		req = batch[0]
+	} else {
+		buf := bytes.NewBuffer(make([]byte, 0, 256*len(batch)))
+		if err := wechatjson.NewEncoder(buf).Encode(batch); err != nil {
+			mch.LogInfoln("[WECHAT_REPORT] encode trades error:", err)
+			return
+		}
+		req = map[string]string{
+			"user_ip": batch[0]["user_ip"],
+			"time":    time.Now().In(beijingLocation).Format("20060102150405"),
+			"trades":  string(bytes.TrimSpace(buf.Bytes())),
+		}
+	}
+	req["appid"] = reporter.appId
+	req["mch_id"] = reporter.mchId
+	req["nonce_str"] = wechatutil.NonceStr()
+	req["sign"] = mch.Sign(req, reporter.proxy.APIKey(), nil)
+
+	if _, err := Report(reporter.proxy, req); err != nil {
+		mch.LogInfoln("[WECHAT_REPORT] count:", len(batch), "error:", err)
+	}
+}
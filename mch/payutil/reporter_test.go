@@ -0,0 +1,90 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package payutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/c77cc/wechat/mch"
+	"github.com/c77cc/wechat/mch/mchtest"
+)
+
+func TestReporter(t *testing.T) {
+	const (
+		appId  = "wx2421b1c4370ec43b"
+		mchId  = "10000100"
+		apiKey = "192006250b4c09247ec02edce69f6a2d"
+	)
+	srv := mchtest.NewServer(appId, mchId, apiKey)
+	defer srv.Close()
+
+	var (
+		mutex   sync.Mutex
+		reports []map[string]string
+	)
+	srv.HandleFunc("/payitil/report", func(req map[string]string) map[string]string {
+		mutex.Lock()
+		reports = append(reports, req)
+		mutex.Unlock()
+		return map[string]string{"result_code": mch.ResultCodeSuccess}
+	})
+	// 返回收到的每次上报的条数
+	counts := func() (counts []int) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		for _, req := range reports {
+			if req["trades"] == "" {
+				counts = append(counts, 1)
+				continue
+			}
+			var trades []map[string]string
+			if err := json.Unmarshal([]byte(req["trades"]), &trades); err != nil {
+				t.Error(err)
+				return nil
+			}
+			counts = append(counts, len(trades))
+		}
+		reports = nil
+		return
+	}
+	observe := func(reporter *Reporter, n int) {
+		for i := 0; i < n; i++ {
+			reporter.ObserveCall(&mch.CallStat{
+				URL:       "https://api.mch.weixin.qq.com/pay/orderquery",
+				Request:   map[string]string{"out_trade_no": fmt.Sprintf("order%d", i)},
+				Response:  map[string]string{"result_code": mch.ResultCodeSuccess},
+				StartTime: time.Now(),
+				Duration:  time.Millisecond,
+			})
+		}
+	}
+
+	proxy := mch.NewProxy(apiKey, nil)
+	proxy.SetEndpoint(srv.Endpoint())
+
+	// 攒够 batchSize 条就上报, 剩下的在 Close 的时候上报
+	reporter := newReporter(proxy, appId, mchId, "8.8.8.8", 0, 3, time.Hour)
+	observe(reporter, 7)
+	reporter.Close()
+	if have := fmt.Sprint(counts()); have != "[3 3 1]" {
+		t.Errorf("got report counts %s, want [3 3 1]", have)
+		return
+	}
+
+	// 不够 batchSize 条的时候每隔 flushInterval 上报一次
+	reporter = newReporter(proxy, appId, mchId, "8.8.8.8", 0, 100, time.Millisecond*20)
+	defer reporter.Close()
+	observe(reporter, 2)
+	time.Sleep(time.Millisecond * 100)
+	if have := fmt.Sprint(counts()); have != "[2]" {
+		t.Errorf("got report counts %s, want [2]", have)
+	}
+}
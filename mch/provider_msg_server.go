@@ -0,0 +1,101 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mch
+
+import (
+	"encoding/xml"
+	"net/http"
+	"sync"
+)
+
+var (
+	_ MessageServer  = (*ProviderMessageServer)(nil)
+	_ MessageHandler = (*ProviderMessageServer)(nil)
+)
+
+// 服务商模式的 MessageServer.
+//  用服务商的 appid, mch_id 和 API密钥 验证通知, 然后根据通知的 sub_mch_id 把通知交给对应子商户的 MessageHandler;
+//  没有找到子商户的 MessageHandler 则交给 defaultHandler, defaultHandler 为 nil 的话应答 FAIL, 微信支付会稍后重新通知.
+//
+//  ProviderMessageServer 并发安全，可以在运行中动态增加和删除子商户的 MessageHandler。
+type ProviderMessageServer struct {
+	appId  string
+	mchId  string
+	apiKey string
+
+	rwmutex        sync.RWMutex
+	handlerMap     map[string]MessageHandler // sub_mch_id => MessageHandler
+	defaultHandler MessageHandler
+}
+
+// 创建服务商模式的 MessageServer, appId, mchId, apiKey 都是服务商的.
+func NewProviderMessageServer(appId, mchId, apiKey string, defaultHandler MessageHandler) *ProviderMessageServer {
+	return &ProviderMessageServer{
+		appId:          appId,
+		mchId:          mchId,
+		apiKey:         apiKey,
+		handlerMap:     make(map[string]MessageHandler),
+		defaultHandler: defaultHandler,
+	}
+}
+
+func (srv *ProviderMessageServer) AppId() string {
+	return srv.appId
+}
+func (srv *ProviderMessageServer) MchId() string {
+	return srv.mchId
+}
+func (srv *ProviderMessageServer) APIKey() string {
+	return srv.apiKey
+}
+func (srv *ProviderMessageServer) MessageHandler() MessageHandler {
+	return srv
+}
+
+// 设置子商户 subMchId 的 MessageHandler.
+// 如果 subMchId == "" 或者 handler == nil 则不做任何操作
+func (srv *ProviderMessageServer) SetSubMchHandler(subMchId string, handler MessageHandler) {
+	if subMchId == "" {
+		return
+	}
+	if handler == nil {
+		return
+	}
+
+	srv.rwmutex.Lock()
+	srv.handlerMap[subMchId] = handler
+	srv.rwmutex.Unlock()
+}
+
+// 删除子商户 subMchId 的 MessageHandler
+func (srv *ProviderMessageServer) DeleteSubMchHandler(subMchId string) {
+	srv.rwmutex.Lock()
+	delete(srv.handlerMap, subMchId)
+	srv.rwmutex.Unlock()
+}
+
+// 根据 sub_mch_id 分发通知.
+func (srv *ProviderMessageServer) ServeMessage(w http.ResponseWriter, r *Request) {
+	subMchId := r.Msg["sub_mch_id"]
+
+	srv.rwmutex.RLock()
+	handler := srv.handlerMap[subMchId]
+	if handler == nil {
+		handler = srv.defaultHandler
+	}
+	srv.rwmutex.RUnlock()
+
+	if handler == nil {
+		LogInfoln("[WECHAT_PROVIDER] no MessageHandler for sub_mch_id:", subMchId)
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		xml.NewEncoder(w).Encode(&Error{
+			ReturnCode: ReturnCodeFail,
+			ReturnMsg:  "unknown sub_mch_id: " + subMchId,
+		})
+		return
+	}
+	handler.ServeMessage(w, r)
+}
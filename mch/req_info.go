@@ -0,0 +1,126 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mch
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+
+	"github.com/c77cc/util"
+)
+
+// 解密退款结果通知的 req_info.
+//  req_info 是 base64 编码的 AES-256-ECB 密文, 密钥为 API密钥 的 md5(32位小写), PKCS#7 补位.
+func DecryptReqInfo(reqInfo, apiKey string) (rawXML []byte, err error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(reqInfo)
+	if err != nil {
+		return
+	}
+	block, err := aes.NewCipher(reqInfoKey(apiKey))
+	if err != nil {
+		return
+	}
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		err = errors.New("invalid req_info: ciphertext is not a multiple of the block size")
+		return
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	for i := 0; i < len(ciphertext); i += aes.BlockSize {
+		block.Decrypt(plaintext[i:i+aes.BlockSize], ciphertext[i:i+aes.BlockSize])
+	}
+
+	// PKCS#7 去除补位
+	amountToPad := int(plaintext[len(plaintext)-1])
+	if amountToPad < 1 || amountToPad > aes.BlockSize || amountToPad > len(plaintext) {
+		err = errors.New("invalid req_info: incorrect padding")
+		return
+	}
+	for _, b := range plaintext[len(plaintext)-amountToPad:] {
+		if int(b) != amountToPad {
+			err = errors.New("invalid req_info: incorrect padding")
+			return
+		}
+	}
+	return plaintext[:len(plaintext)-amountToPad], nil
+}
+
+// 加密 req_info, 是 DecryptReqInfo 的逆运算, 一般用于模拟退款结果通知.
+func EncryptReqInfo(rawXML []byte, apiKey string) (reqInfo string, err error) {
+	block, err := aes.NewCipher(reqInfoKey(apiKey))
+	if err != nil {
+		return
+	}
+
+	// PKCS#7 补位
+	amountToPad := aes.BlockSize - len(rawXML)%aes.BlockSize
+	plaintext := make([]byte, len(rawXML)+amountToPad)
+	copy(plaintext, rawXML)
+	copy(plaintext[len(rawXML):], bytes.Repeat([]byte{byte(amountToPad)}, amountToPad))
+
+	ciphertext := make([]byte, len(plaintext))
+	for i := 0; i < len(plaintext); i += aes.BlockSize {
+		block.Encrypt(ciphertext[i:i+aes.BlockSize], plaintext[i:i+aes.BlockSize])
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func reqInfoKey(apiKey string) []byte {
+	sum := md5.Sum([]byte(apiKey))
+	key := make([]byte, hex.EncodedLen(len(sum)))
+	hex.Encode(key, sum[:])
+	return key
+}
+
+// 退款结果通知外层的字段, 其他的消息都有签名.
+var refundNotifyFields = map[string]bool{
+	"return_code": true,
+	"return_msg":  true,
+	"appid":       true,
+	"mch_id":      true,
+	"sub_appid":   true,
+	"sub_mch_id":  true,
+	"nonce_str":   true,
+	"req_info":    true,
+}
+
+// 判断 msg 是否为退款结果通知: 没有 sign, 有 req_info, 并且没有退款结果通知外层以外的字段.
+func isRefundNotify(msg map[string]string) bool {
+	if _, ok := msg["sign"]; ok {
+		return false
+	}
+	if msg["req_info"] == "" {
+		return false
+	}
+	for name := range msg {
+		if !refundNotifyFields[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// 解密退款结果通知的 req_info, 并且校验解密的结果是退款结果通知的 XML.
+//  退款结果通知没有签名, 只校验 PKCS#7 补位的话随机的密文大约有 1/256 的概率能够"解密"成功.
+func decryptRefundReqInfo(reqInfo, apiKey string) (rawXML []byte, err error) {
+	if rawXML, err = DecryptReqInfo(reqInfo, apiKey); err != nil {
+		return
+	}
+	m, err := util.ParseXMLToMap(bytes.NewReader(rawXML))
+	if err != nil {
+		return nil, errors.New("invalid req_info: " + err.Error())
+	}
+	for _, name := range []string{"out_refund_no", "refund_id", "refund_status"} {
+		if m[name] == "" {
+			return nil, errors.New("invalid req_info: no " + name)
+		}
+	}
+	return
+}
@@ -0,0 +1,96 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mch
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestReqInfo(t *testing.T) {
+	const apiKey = "192006250b4c09247ec02edce69f6a2d"
+
+	tests := [][]byte{
+		[]byte("<root><out_refund_no>R1</out_refund_no><refund_status>SUCCESS</refund_status></root>"),
+		bytes.Repeat([]byte("a"), 16), // 刚好一个块, 补位一整块
+		[]byte{},
+	}
+	for _, rawXML := range tests {
+		reqInfo, err := EncryptReqInfo(rawXML, apiKey)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		have, err := DecryptReqInfo(reqInfo, apiKey)
+		if err != nil {
+			t.Errorf("DecryptReqInfo(EncryptReqInfo(%q)) failed: %v", rawXML, err)
+			continue
+		}
+		if !bytes.Equal(have, rawXML) {
+			t.Errorf("DecryptReqInfo(EncryptReqInfo(%q)) = %q", rawXML, have)
+		}
+	}
+
+	// 用 openssl 生成的密文, 密钥为 apiKey 的 md5(32位小写):
+	//  printf '<root>ok</root>' | openssl enc -aes-256-ecb -K <hex(md5(apiKey))> | base64
+	const opensslReqInfo = "QlFBknq4eRoVzHRrNLqPsw=="
+	if have, err := DecryptReqInfo(opensslReqInfo, apiKey); err != nil || string(have) != "<root>ok</root>" {
+		t.Errorf("DecryptReqInfo(%q) = %q, %v", opensslReqInfo, have, err)
+	}
+	if have, _ := EncryptReqInfo([]byte("<root>ok</root>"), apiKey); have != opensslReqInfo {
+		t.Errorf("EncryptReqInfo = %q, want %q", have, opensslReqInfo)
+	}
+
+	invalid := []string{
+		"not base64!",
+		base64.StdEncoding.EncodeToString([]byte("short")),
+		"",
+	}
+	for _, reqInfo := range invalid {
+		if _, err := DecryptReqInfo(reqInfo, apiKey); err == nil {
+			t.Errorf("DecryptReqInfo(%q) should fail", reqInfo)
+		}
+	}
+}
+
+func TestRefundNotify(t *testing.T) {
+	const apiKey = "192006250b4c09247ec02edce69f6a2d"
+
+	msg := map[string]string{
+		"return_code": ReturnCodeSuccess,
+		"appid":       "wx2421b1c4370ec43b",
+		"mch_id":      "10000100",
+		"nonce_str":   "TeqClE3i0mvn3DrK",
+		"req_info":    "QlFBknq4eRoVzHRrNLqPsw==",
+	}
+	if !isRefundNotify(msg) {
+		t.Errorf("isRefundNotify(%v) should be true", msg)
+	}
+	msg["result_code"] = ResultCodeSuccess // 伪造的支付结果通知
+	if isRefundNotify(msg) {
+		t.Errorf("isRefundNotify(%v) should be false", msg)
+	}
+	delete(msg, "result_code")
+	msg["sign"] = ""
+	if isRefundNotify(msg) {
+		t.Errorf("isRefundNotify(%v) should be false", msg)
+	}
+
+	// 能够解密, 但是不是退款结果通知
+	if _, err := decryptRefundReqInfo("QlFBknq4eRoVzHRrNLqPsw==", apiKey); err == nil {
+		t.Error("decryptRefundReqInfo should fail for <root>ok</root>")
+	}
+	rawXML := []byte("<root><out_refund_no>R1</out_refund_no><refund_id>50000408</refund_id><refund_status>SUCCESS</refund_status></root>")
+	reqInfo, err := EncryptReqInfo(rawXML, apiKey)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if have, err := decryptRefundReqInfo(reqInfo, apiKey); err != nil || !bytes.Equal(have, rawXML) {
+		t.Errorf("decryptRefundReqInfo = %q, %v", have, err)
+	}
+}
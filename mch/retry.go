@@ -0,0 +1,29 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mch
+
+import (
+	wechatutil "github.com/c77cc/wechat/util"
+)
+
+// 默认的重试策略, 所有没有通过 SetRetryPolicy 指定策略的 Proxy 都使用这个策略; 默认为 nil, 不重试.
+//  微信支付只对 http 5xx 重试, 下单, 退款, 付款等接口的请求被 wechatutil.NonIdempotent 标记, 不会重试.
+//  沒有加锁, 请确保在初始化阶段修改!
+var DefaultRetryPolicy *wechatutil.RetryPolicy
+
+// 设置 proxy 的重试策略, 如果 policy == nil 则使用 DefaultRetryPolicy.
+//  沒有加锁, 请确保在初始化阶段调用!
+func (proxy *Proxy) SetRetryPolicy(policy *wechatutil.RetryPolicy) {
+	proxy.retryPolicy = policy
+}
+
+// 返回 proxy 实际使用的重试策略.
+func (proxy *Proxy) RetryPolicy() *wechatutil.RetryPolicy {
+	if proxy.retryPolicy != nil {
+		return proxy.retryPolicy
+	}
+	return DefaultRetryPolicy
+}
@@ -0,0 +1,136 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/c77cc/util"
+	wechatutil "github.com/c77cc/wechat/util"
+)
+
+// 仿真测试系统的 api 地址前缀
+const sandboxURLPrefix = wechatutil.DefaultMchAPIBaseURL + "/sandboxnew/"
+
+// 仿真测试系统的配置和缓存的验签密钥
+type sandbox struct {
+	mchId string
+
+	mutex   sync.Mutex
+	signKey string
+}
+
+// 开启仿真测试模式, 用于上线前的验收测试.
+//  开启后 proxy 的所有请求都被转到 https://api.mch.weixin.qq.com/sandboxnew/ 下面的对应接口,
+//  请求用仿真测试系统的验签密钥重新签名, 返回的数据也用这个密钥验证签名;
+//  验签密钥在第一次请求的时候通过 /sandboxnew/pay/getsignkey 获取并缓存.
+//  沒有加锁, 请确保在初始化阶段调用!
+func (proxy *Proxy) EnableSandbox(mchId string) {
+	proxy.sandbox = &sandbox{
+		mchId: mchId,
+	}
+}
+
+// 是否开启了仿真测试模式.
+func (proxy *Proxy) IsSandbox() bool {
+	return proxy.sandbox != nil
+}
+
+// 获取仿真测试系统的验签密钥, 获取成功后缓存起来.
+func (proxy *Proxy) SandboxSignKey(ctx context.Context) (signKey string, err error) {
+	sb := proxy.sandbox
+	if sb == nil {
+		err = errors.New("sandbox is not enabled")
+		return
+	}
+
+	sb.mutex.Lock()
+	defer sb.mutex.Unlock()
+
+	if sb.signKey != "" {
+		return sb.signKey, nil
+	}
+	if signKey, err = proxy.getSandboxSignKey(ctx, sb.mchId); err != nil {
+		return
+	}
+	sb.signKey = signKey
+	return
+}
+
+func (proxy *Proxy) getSandboxSignKey(ctx context.Context, mchId string) (signKey string, err error) {
+	req := make(map[string]string, 3)
+	req["mch_id"] = mchId
+	req["nonce_str"] = wechatutil.NonceStr()
+	req["sign"] = Sign(req, proxy.apiKey, nil)
+
+	bodyBuf := bytes.NewBuffer(make([]byte, 0, 256))
+	if err = util.FormatMapToXML(bodyBuf, req); err != nil {
+		return
+	}
+
+	httpResp, err := wechatutil.HttpPostContext(ctx, proxy.httpClient, proxy.EndpointURL(sandboxURLPrefix+"pay/getsignkey"), "text/xml; charset=utf-8", bodyBuf)
+	if err != nil {
+		return
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		err = &wechatutil.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
+		return
+	}
+
+	// getsignkey 的返回没有签名
+	resp, err := util.ParseXMLToMap(httpResp.Body)
+	if err != nil {
+		return
+	}
+	if ReturnCode := resp["return_code"]; ReturnCode != ReturnCodeSuccess {
+		err = &Error{
+			ReturnCode: ReturnCode,
+			ReturnMsg:  resp["return_msg"],
+		}
+		return
+	}
+	if signKey = resp["sandbox_signkey"]; signKey == "" {
+		err = errors.New("no sandbox_signkey parameter")
+		return
+	}
+	return
+}
+
+// 把请求转换为仿真测试系统的请求, 返回转换后的 url, req 和验签密钥.
+//  req 有 sign 的话用验签密钥重新签名, 原来的 req 不会被修改.
+func (proxy *Proxy) sandboxRequest(ctx context.Context, url string, req map[string]string) (url2 string, req2 map[string]string, signKey string, err error) {
+	if signKey, err = proxy.SandboxSignKey(ctx); err != nil {
+		return
+	}
+
+	url2 = sandboxURL(url)
+	if _, ok := req["sign"]; !ok {
+		return url2, req, signKey, nil
+	}
+	req2 = make(map[string]string, len(req))
+	for k, v := range req {
+		req2[k] = v
+	}
+	if req2["sign"], err = SignWithType(req2, signKey, req2["sign_type"]); err != nil {
+		return
+	}
+	return
+}
+
+// https://api.mch.weixin.qq.com/pay/unifiedorder => https://api.mch.weixin.qq.com/sandboxnew/pay/unifiedorder
+func sandboxURL(url string) string {
+	if !strings.HasPrefix(url, wechatutil.DefaultMchAPIBaseURL+"/") || strings.HasPrefix(url, sandboxURLPrefix) {
+		return url
+	}
+	return sandboxURLPrefix + url[len(wechatutil.DefaultMchAPIBaseURL)+1:]
+}
@@ -15,6 +15,7 @@ import (
 	"net/url"
 
 	"github.com/c77cc/util"
+	wechatutil "github.com/c77cc/wechat/util"
 )
 
 func ServeHTTP(w http.ResponseWriter, r *http.Request, queryValues url.Values,
@@ -34,6 +35,7 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, queryValues url.Values,
 			return
 		}
 
+		var ReqInfoXML []byte
 		ReturnCode, ok := msg["return_code"]
 		if !ok || ReturnCode == ReturnCodeSuccess {
 			haveAppId := msg["appid"]
@@ -62,23 +64,37 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, queryValues url.Values,
 				return
 			}
 
-			// 认证签名
-			signature1, ok := msg["sign"]
-			if !ok {
-				err = errors.New("no sign parameter")
-				invalidRequestHandler.ServeInvalidRequest(w, r, err)
-				return
-			}
-			signature2 := Sign(msg, messageServer.APIKey(), nil)
-			if len(signature1) != len(signature2) {
-				err = fmt.Errorf("check signature failed, \r\ninput: %q, \r\nlocal: %q", signature1, signature2)
-				invalidRequestHandler.ServeInvalidRequest(w, r, err)
-				return
-			}
-			if subtle.ConstantTimeCompare([]byte(signature1), []byte(signature2)) != 1 {
-				err = fmt.Errorf("check signature failed, \r\ninput: %q, \r\nlocal: %q", signature1, signature2)
-				invalidRequestHandler.ServeInvalidRequest(w, r, err)
-				return
+			// 退款结果通知没有签名, 能够用 API密钥 解密 req_info 并且得到退款结果的 XML 就认为是合法的请求;
+			// 其他的消息都要认证签名.
+			if isRefundNotify(msg) {
+				ReqInfoXML, err = decryptRefundReqInfo(msg["req_info"], messageServer.APIKey())
+				if err != nil {
+					invalidRequestHandler.ServeInvalidRequest(w, r, err)
+					return
+				}
+			} else {
+				// 认证签名
+				signature1, ok := msg["sign"]
+				if !ok {
+					err = errors.New("no sign parameter")
+					invalidRequestHandler.ServeInvalidRequest(w, r, err)
+					return
+				}
+				signature2, err := SignWithType(msg, messageServer.APIKey(), msg["sign_type"])
+				if err != nil {
+					invalidRequestHandler.ServeInvalidRequest(w, r, err)
+					return
+				}
+				if len(signature1) != len(signature2) {
+					err = &wechatutil.SignatureError{Name: "sign", Input: signature1, Local: signature2}
+					invalidRequestHandler.ServeInvalidRequest(w, r, err)
+					return
+				}
+				if subtle.ConstantTimeCompare([]byte(signature1), []byte(signature2)) != 1 {
+					err = &wechatutil.SignatureError{Name: "sign", Input: signature1, Local: signature2}
+					invalidRequestHandler.ServeInvalidRequest(w, r, err)
+					return
+				}
 			}
 		}
 
@@ -87,6 +103,8 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, queryValues url.Values,
 
 			RawMsgXML: RawMsgXML,
 			Msg:       msg,
+
+			ReqInfoXML: ReqInfoXML,
 		}
 		messageServer.MessageHandler().ServeMessage(w, req)
 
@@ -7,16 +7,26 @@ package mch
 
 import (
 	"bytes"
+	"crypto/hmac"
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"hash"
 	"sort"
 )
 
+// 签名类型, 对应参数 sign_type
+const (
+	SignTypeMD5        = "MD5"
+	SignTypeHMACSHA256 = "HMAC-SHA256"
+)
+
 // 微信支付签名.
 //  parameters: 待签名的参数集合
 //  apiKey:     API密钥
 //  fn:         func() hash.Hash, 如果 fn == nil 则默认用 md5.New
+//  NOTE: fn 只是普通的摘要算法, HMAC-SHA256 签名请用 SignWithType.
 func Sign(parameters map[string]string, apiKey string, fn func() hash.Hash) string {
 	ks := make([]string, 0, len(parameters))
 	for k := range parameters {
@@ -49,3 +59,16 @@ func Sign(parameters map[string]string, apiKey string, fn func() hash.Hash) stri
 	hex.Encode(signature, h.Sum(nil))
 	return string(bytes.ToUpper(signature))
 }
+
+// 按照签名类型 signType 签名, signType 为空时默认为 SignTypeMD5.
+//  HMAC-SHA256 以 apiKey 作为 HMAC 的密钥, 待签名的字符串和 MD5 一样也要拼接上 "key=apiKey".
+func SignWithType(parameters map[string]string, apiKey, signType string) (string, error) {
+	switch signType {
+	case "", SignTypeMD5:
+		return Sign(parameters, apiKey, md5.New), nil
+	case SignTypeHMACSHA256:
+		return Sign(parameters, apiKey, func() hash.Hash { return hmac.New(sha256.New, []byte(apiKey)) }), nil
+	default:
+		return "", fmt.Errorf("unsupported sign_type: %s", signType)
+	}
+}
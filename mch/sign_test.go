@@ -0,0 +1,61 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mch
+
+import (
+	"testing"
+)
+
+func TestSignWithType(t *testing.T) {
+	// 微信支付文档里的示例
+	params := map[string]string{
+		"appid":       "wxd930ea5d5a258f4f",
+		"mch_id":      "10000100",
+		"device_info": "1000",
+		"body":        "test",
+		"nonce_str":   "ibuaiVcKdpRxkhJA",
+	}
+	const apiKey = "192006250b4c09247ec02edce69f6a2d"
+
+	tests := []struct {
+		signType string
+		want     string
+	}{
+		{"", "9A0A8659F005D6984697E2CA0A9CF3B7"},
+		{SignTypeMD5, "9A0A8659F005D6984697E2CA0A9CF3B7"},
+		{SignTypeHMACSHA256, "6A9AE1657590FD6257D693A078E1C3E4BB6BA4DC30B23E0EE2496E54170DACD6"},
+	}
+	for _, test := range tests {
+		have, err := SignWithType(params, apiKey, test.signType)
+		if err != nil {
+			t.Errorf("SignWithType(%q) failed: %v", test.signType, err)
+			continue
+		}
+		if have != test.want {
+			t.Errorf("SignWithType(%q) = %s, want %s", test.signType, have, test.want)
+		}
+	}
+
+	// 验证签名: sign 不参与签名, 修改参数或者密钥后签名不一致
+	signed := map[string]string{"sign": tests[2].want}
+	for k, v := range params {
+		signed[k] = v
+	}
+	if have, _ := SignWithType(signed, apiKey, SignTypeHMACSHA256); have != signed["sign"] {
+		t.Errorf("verify signed params: have %s, want %s", have, signed["sign"])
+	}
+	signed["body"] = "test2"
+	if have, _ := SignWithType(signed, apiKey, SignTypeHMACSHA256); have == tests[2].want {
+		t.Error("signature should change after params modified")
+	}
+	if have, _ := SignWithType(params, apiKey+"x", SignTypeHMACSHA256); have == tests[2].want {
+		t.Error("signature should change after apiKey modified")
+	}
+
+	if _, err := SignWithType(params, apiKey, "SHA1"); err == nil {
+		t.Error("unsupported sign_type should fail")
+	}
+}
@@ -7,13 +7,13 @@ package account
 
 import (
 	"errors"
-	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 
 	"github.com/c77cc/wechat/mp"
+	"github.com/c77cc/wechat/util"
 )
 
 const (
@@ -246,7 +246,7 @@ func qrcodeDownloadToWriter(ticket string, writer io.Writer, httpClient *http.Cl
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("http.Status: %s", httpResp.Status)
+		return &util.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 	}
 
 	if _, err = io.Copy(writer, httpResp.Body); err != nil {
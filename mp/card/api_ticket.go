@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/c77cc/wechat/mp"
+	"github.com/c77cc/wechat/util"
 )
 
 // jsapi_ticket 中控服务器接口.
@@ -40,7 +41,7 @@ var _ TicketServer = (*DefaultTicketServer)(nil)
 type DefaultTicketServer struct {
 	wechatClient mp.WechatClient
 
-	resetTickerChan chan time.Duration // 用于重置 ticketDaemon 里的 ticker
+	refresher *util.RefreshScheduler // 定时刷新 api_ticket
 
 	ticketGet struct {
 		sync.Mutex
@@ -50,7 +51,8 @@ type DefaultTicketServer struct {
 
 	ticketCache struct {
 		sync.RWMutex
-		Ticket string
+		Ticket    string
+		ExpiresAt time.Time
 	}
 }
 
@@ -69,19 +71,20 @@ func NewDefaultTicketServer(AccessTokenServer mp.AccessTokenServer, httpClient *
 			AccessTokenServer: AccessTokenServer,
 			HttpClient:        httpClient,
 		},
-		resetTickerChan: make(chan time.Duration),
 	}
 
-	go srv.ticketDaemon(time.Hour * 24) // 启动 tokenDaemon
+	srv.refresher = util.NewRefreshScheduler("mp/card/api_ticket", srv.refresh)
 	return
 }
 
 func (srv *DefaultTicketServer) Ticket() (ticket string, err error) {
 	srv.ticketCache.RLock()
 	ticket = srv.ticketCache.Ticket
+	expiresAt := srv.ticketCache.ExpiresAt
 	srv.ticketCache.RUnlock()
 
-	if ticket != "" {
+	// 刷新失败的时候继续使用上一次获取的 api_ticket, 直到过期
+	if ticket != "" && time.Now().Before(expiresAt) {
 		return
 	}
 	return srv.TicketRefresh()
@@ -89,41 +92,32 @@ func (srv *DefaultTicketServer) Ticket() (ticket string, err error) {
 
 func (srv *DefaultTicketServer) TicketRefresh() (ticket string, err error) {
 	ticketInfo, cached, err := srv.getTicket()
+	if !cached {
+		srv.refresher.Refreshed(time.Duration(ticketInfo.ExpiresIn)*time.Second, err)
+	}
 	if err != nil {
 		return
 	}
-	if !cached {
-		srv.resetTickerChan <- time.Duration(ticketInfo.ExpiresIn) * time.Second
-	}
 	ticket = ticketInfo.Ticket
 	return
 }
 
-func (srv *DefaultTicketServer) ticketDaemon(tickDuration time.Duration) {
-NEW_TICK_DURATION:
-	ticker := time.NewTicker(tickDuration)
-
-	for {
-		select {
-		case tickDuration = <-srv.resetTickerChan:
-			ticker.Stop()
-			goto NEW_TICK_DURATION
-
-		case <-ticker.C:
-			ticketInfo, cached, err := srv.getTicket()
-			if err != nil {
-				break
-			}
-			if !cached {
-				newTickDuration := time.Duration(ticketInfo.ExpiresIn) * time.Second
-				if tickDuration != newTickDuration {
-					tickDuration = newTickDuration
-					ticker.Stop()
-					goto NEW_TICK_DURATION
-				}
-			}
-		}
-	}
+// 停止定时刷新 api_ticket 的后台 goroutine, 等待其退出后返回, 可以重复调用.
+//  Close 之后 Ticket 和 TicketRefresh 仍然可用, 只是不再定时刷新.
+func (srv *DefaultTicketServer) Close() error {
+	srv.refresher.Close()
+	return nil
+}
+
+// 设置定时刷新 api_ticket 的事件回调, hook == nil 的时候使用 util.DefaultRefreshHook.
+func (srv *DefaultTicketServer) SetRefreshHook(hook util.RefreshHookFunc) {
+	srv.refresher.SetHook(hook)
+}
+
+// 由 refresher 定时调用.
+func (srv *DefaultTicketServer) refresh() (expiresIn time.Duration, err error) {
+	info, _, err := srv.getTicket()
+	return time.Duration(info.ExpiresIn) * time.Second, err
 }
 
 type ticketInfo struct {
@@ -157,17 +151,10 @@ func (srv *DefaultTicketServer) getTicket() (ticket ticketInfo, cached bool, err
 
 	incompleteURL := "https://api.weixin.qq.com/cgi-bin/ticket/getticket?type=wx_card&access_token="
 	if err = srv.wechatClient.GetJSON(incompleteURL, &result); err != nil {
-		srv.ticketCache.Lock()
-		srv.ticketCache.Ticket = ""
-		srv.ticketCache.Unlock()
 		return
 	}
 
 	if result.ErrCode != mp.ErrCodeOK {
-		srv.ticketCache.Lock()
-		srv.ticketCache.Ticket = ""
-		srv.ticketCache.Unlock()
-
 		err = &result.Error
 		return
 	}
@@ -175,10 +162,6 @@ func (srv *DefaultTicketServer) getTicket() (ticket ticketInfo, cached bool, err
 	// 由于网络的延时, jsapi_ticket 过期时间留了一个缓冲区
 	switch {
 	case result.ExpiresIn > 31556952: // 60*60*24*365.2425
-		srv.ticketCache.Lock()
-		srv.ticketCache.Ticket = ""
-		srv.ticketCache.Unlock()
-
 		err = errors.New("expires_in too large: " + strconv.FormatInt(result.ExpiresIn, 10))
 		return
 	case result.ExpiresIn > 60*60:
@@ -190,10 +173,6 @@ func (srv *DefaultTicketServer) getTicket() (ticket ticketInfo, cached bool, err
 	case result.ExpiresIn > 60:
 		result.ExpiresIn -= 10
 	default:
-		srv.ticketCache.Lock()
-		srv.ticketCache.Ticket = ""
-		srv.ticketCache.Unlock()
-
 		err = errors.New("expires_in too small: " + strconv.FormatInt(result.ExpiresIn, 10))
 		return
 	}
@@ -203,6 +182,7 @@ func (srv *DefaultTicketServer) getTicket() (ticket ticketInfo, cached bool, err
 
 	srv.ticketCache.Lock()
 	srv.ticketCache.Ticket = result.ticketInfo.Ticket
+	srv.ticketCache.ExpiresAt = time.Now().Add(time.Duration(result.ticketInfo.ExpiresIn) * time.Second)
 	srv.ticketCache.Unlock()
 
 	ticket = result.ticketInfo
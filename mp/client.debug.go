@@ -9,20 +9,25 @@ package mp
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"reflect"
 
 	wechatjson "github.com/c77cc/wechat/json"
+	"github.com/c77cc/wechat/util"
 )
 
 // 微信公众号"主动"请求功能的基本封装.
 type WechatClient struct {
 	AccessTokenServer
-	HttpClient *http.Client
+	HttpClient  *http.Client
+	Endpoint    *util.Endpoint    // 如果为 nil 则用 DefaultEndpoint; 获取 access_token 的地址见 DefaultAccessTokenServer.SetEndpoint
+	RetryPolicy *util.RetryPolicy // 如果为 nil 则用 DefaultRetryPolicy
+
+	ctx context.Context // 见 WithContext
 }
 
 // 创建一个新的 WechatClient.
@@ -53,6 +58,11 @@ func NewWechatClient(srv AccessTokenServer, clt *http.Client) *WechatClient {
 //          ...
 //      }
 func (clt *WechatClient) PostJSON(incompleteURL string, request interface{}, response interface{}) (err error) {
+	return clt.PostJSONContext(clt.Context(), incompleteURL, request, response)
+}
+
+// PostJSON 的 context 版本, ctx 用于取消请求或者设置请求的超时.
+func (clt *WechatClient) PostJSONContext(ctx context.Context, incompleteURL string, request interface{}, response interface{}) (err error) {
 	buf := textBufferPool.Get().(*bytes.Buffer)
 	buf.Reset()
 	defer textBufferPool.Put(buf)
@@ -67,21 +77,31 @@ func (clt *WechatClient) PostJSON(incompleteURL string, request interface{}, res
 		return
 	}
 
+	retryPolicy := clt.retryPolicy()
+	attempt := 0
 	hasRetried := false
 RETRY:
-	finalURL := incompleteURL + url.QueryEscape(token)
+	attempt++
+	finalURL := clt.EndpointURL(incompleteURL) + url.QueryEscape(token)
 
 	LogInfoln("[WECHAT_DEBUG] request url:", finalURL)
 	LogInfoln("[WECHAT_DEBUG] request json:", string(requestBytes))
 
-	httpResp, err := clt.HttpClient.Post(finalURL, "application/json; charset=utf-8", bytes.NewReader(requestBytes))
+	httpResp, err := util.HttpPostContext(ctx, clt.HttpClient, finalURL, "application/json; charset=utf-8", bytes.NewReader(requestBytes))
 	if err != nil {
 		return
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("http.Status: %s", httpResp.Status)
+		if retryPolicy.ShouldRetryHTTPStatus(ctx, attempt, httpResp.StatusCode) {
+			LogInfoln("[WECHAT_RETRY] http.Status:", httpResp.Status, ", attempt:", attempt)
+			if err = retryPolicy.Wait(ctx, attempt); err != nil {
+				return
+			}
+			goto RETRY
+		}
+		return &util.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 	}
 
 	respBody, err := ioutil.ReadAll(httpResp.Body)
@@ -126,6 +146,14 @@ RETRY:
 		LogInfoln("[WECHAT_RETRY] fallthrough, current token:", token)
 		fallthrough
 	default:
+		if retryPolicy.ShouldRetry(ctx, attempt, ErrCode) {
+			LogInfoln("[WECHAT_RETRY] err_code:", ErrCode, ", attempt:", attempt)
+			if err = retryPolicy.Wait(ctx, attempt); err != nil {
+				return
+			}
+			responseStructValue.Set(reflect.New(responseStructValue.Type()).Elem())
+			goto RETRY
+		}
 		return
 	}
 }
@@ -141,23 +169,38 @@ RETRY:
 //          ...
 //      }
 func (clt *WechatClient) GetJSON(incompleteURL string, response interface{}) (err error) {
+	return clt.GetJSONContext(clt.Context(), incompleteURL, response)
+}
+
+// GetJSON 的 context 版本, ctx 用于取消请求或者设置请求的超时.
+func (clt *WechatClient) GetJSONContext(ctx context.Context, incompleteURL string, response interface{}) (err error) {
 	token, err := clt.Token()
 	if err != nil {
 		return
 	}
 
+	retryPolicy := clt.retryPolicy()
+	attempt := 0
 	hasRetried := false
 RETRY:
-	finalURL := incompleteURL + url.QueryEscape(token)
+	attempt++
+	finalURL := clt.EndpointURL(incompleteURL) + url.QueryEscape(token)
 
-	httpResp, err := clt.HttpClient.Get(finalURL)
+	httpResp, err := util.HttpGetContext(ctx, clt.HttpClient, finalURL)
 	if err != nil {
 		return
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("http.Status: %s", httpResp.Status)
+		if retryPolicy.ShouldRetryHTTPStatus(ctx, attempt, httpResp.StatusCode) {
+			LogInfoln("[WECHAT_RETRY] http.Status:", httpResp.Status, ", attempt:", attempt)
+			if err = retryPolicy.Wait(ctx, attempt); err != nil {
+				return
+			}
+			goto RETRY
+		}
+		return &util.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 	}
 
 	respBody, err := ioutil.ReadAll(httpResp.Body)
@@ -203,6 +246,14 @@ RETRY:
 		LogInfoln("[WECHAT_RETRY] fallthrough, current token:", token)
 		fallthrough
 	default:
+		if retryPolicy.ShouldRetry(ctx, attempt, ErrCode) {
+			LogInfoln("[WECHAT_RETRY] err_code:", ErrCode, ", attempt:", attempt)
+			if err = retryPolicy.Wait(ctx, attempt); err != nil {
+				return
+			}
+			responseStructValue.Set(reflect.New(responseStructValue.Type()).Elem())
+			goto RETRY
+		}
 		return
 	}
 }
@@ -11,7 +11,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
@@ -19,6 +18,7 @@ import (
 	"time"
 
 	"github.com/c77cc/wechat/mp"
+	"github.com/c77cc/wechat/util"
 )
 
 // component_access_token 中控服务器接口.
@@ -40,16 +40,22 @@ var _ AccessTokenServer = (*DefaultAccessTokenServer)(nil)
 
 // AccessTokenServer 的简单实现.
 //  NOTE:
-//  1. 用于单进程环境.
+//  1. 不指定 TokenStore 的时候用于单进程环境;
 //  2. 因为 DefaultAccessTokenServer 同时也是一个简单的中控服务器, 而不是仅仅实现 AccessTokenServer 接口,
-//     所以整个系统只能存在一个 DefaultAccessTokenServer 实例!
+//     所以不指定 TokenStore 的时候整个系统只能存在一个 DefaultAccessTokenServer 实例!
+//  3. 多进程环境下请用 NewDefaultAccessTokenServerWithStore 创建, 所有进程共享同一个 TokenStore,
+//     同一时刻只有一个进程去微信服务器刷新 component_access_token.
 type DefaultAccessTokenServer struct {
 	appId              string
 	appSecret          string
 	verifyTicketGetter VerifyTicketGetter
 	httpClient         *http.Client
+	endpoint           *util.Endpoint // 见 SetEndpoint
 
-	resetTickerChan chan time.Duration // 用于重置 tokenDaemon 里的 ticker
+	tokenStore    util.TokenStore // 多进程共享 component_access_token 的存储, 可以为 nil
+	tokenStoreKey string
+
+	refresher *util.RefreshScheduler // 定时刷新 component_access_token
 
 	tokenGet struct {
 		sync.Mutex
@@ -59,13 +65,23 @@ type DefaultAccessTokenServer struct {
 
 	tokenCache struct {
 		sync.RWMutex
-		Token string
+		Token     string
+		ExpiresAt time.Time
 	}
 }
 
 // 创建一个新的 DefaultAccessTokenServer.
 //  如果 clt == nil 则默认使用 http.DefaultClient.
 func NewDefaultAccessTokenServer(appId, appSecret string, ticketGetter VerifyTicketGetter, clt *http.Client) (srv *DefaultAccessTokenServer) {
+	return NewDefaultAccessTokenServerWithStore(appId, appSecret, ticketGetter, nil, clt)
+}
+
+// 创建一个新的 DefaultAccessTokenServer, 通过 store 和其他进程共享 component_access_token.
+//  如果 store == nil 则等价于 NewDefaultAccessTokenServer;
+//  如果 clt == nil 则默认使用 http.DefaultClient.
+func NewDefaultAccessTokenServerWithStore(appId, appSecret string, ticketGetter VerifyTicketGetter,
+	store util.TokenStore, clt *http.Client) (srv *DefaultAccessTokenServer) {
+
 	if appId == "" {
 		panic("empty appId")
 	}
@@ -84,19 +100,22 @@ func NewDefaultAccessTokenServer(appId, appSecret string, ticketGetter VerifyTic
 		appSecret:          appSecret,
 		verifyTicketGetter: ticketGetter,
 		httpClient:         clt,
-		resetTickerChan:    make(chan time.Duration),
+		tokenStore:         store,
+		tokenStoreKey:      "component/access_token/" + appId,
 	}
 
-	go srv.tokenDaemon(time.Hour * 24) // 启动 tokenDaemon
+	srv.refresher = util.NewRefreshScheduler(srv.tokenStoreKey, srv.refresh)
 	return
 }
 
 func (srv *DefaultAccessTokenServer) Token() (token string, err error) {
 	srv.tokenCache.RLock()
 	token = srv.tokenCache.Token
+	expiresAt := srv.tokenCache.ExpiresAt
 	srv.tokenCache.RUnlock()
 
-	if token != "" {
+	// 刷新失败的时候继续使用上一次获取的 component_access_token, 直到过期
+	if token != "" && time.Now().Before(expiresAt) {
 		return
 	}
 	return srv.TokenRefresh()
@@ -104,41 +123,32 @@ func (srv *DefaultAccessTokenServer) Token() (token string, err error) {
 
 func (srv *DefaultAccessTokenServer) TokenRefresh() (token string, err error) {
 	accessTokenInfo, cached, err := srv.getToken()
+	if !cached {
+		srv.refresher.Refreshed(time.Duration(accessTokenInfo.ExpiresIn)*time.Second, err)
+	}
 	if err != nil {
 		return
 	}
-	if !cached {
-		srv.resetTickerChan <- time.Duration(accessTokenInfo.ExpiresIn) * time.Second
-	}
 	token = accessTokenInfo.Token
 	return
 }
 
-func (srv *DefaultAccessTokenServer) tokenDaemon(tickDuration time.Duration) {
-NEW_TICK_DURATION:
-	ticker := time.NewTicker(tickDuration)
-
-	for {
-		select {
-		case tickDuration = <-srv.resetTickerChan:
-			ticker.Stop()
-			goto NEW_TICK_DURATION
-
-		case <-ticker.C:
-			accessTokenInfo, cached, err := srv.getToken()
-			if err != nil {
-				break
-			}
-			if !cached {
-				newTickDuration := time.Duration(accessTokenInfo.ExpiresIn) * time.Second
-				if tickDuration != newTickDuration {
-					tickDuration = newTickDuration
-					ticker.Stop()
-					goto NEW_TICK_DURATION
-				}
-			}
-		}
-	}
+// 停止定时刷新 component_access_token 的后台 goroutine, 等待其退出后返回, 可以重复调用.
+//  Close 之后 Token 和 TokenRefresh 仍然可用, 只是不再定时刷新.
+func (srv *DefaultAccessTokenServer) Close() error {
+	srv.refresher.Close()
+	return nil
+}
+
+// 设置定时刷新 component_access_token 的事件回调, hook == nil 的时候使用 util.DefaultRefreshHook.
+func (srv *DefaultAccessTokenServer) SetRefreshHook(hook util.RefreshHookFunc) {
+	srv.refresher.SetHook(hook)
+}
+
+// 由 refresher 定时调用.
+func (srv *DefaultAccessTokenServer) refresh() (expiresIn time.Duration, err error) {
+	info, _, err := srv.getToken()
+	return time.Duration(info.ExpiresIn) * time.Second, err
 }
 
 type accessTokenInfo struct {
@@ -165,11 +175,42 @@ func (srv *DefaultAccessTokenServer) getToken() (token accessTokenInfo, cached b
 		return
 	}
 
+	var info accessTokenInfo
+	if srv.tokenStore != nil {
+		srv.tokenCache.RLock()
+		stale := srv.tokenCache.Token
+		srv.tokenCache.RUnlock()
+
+		info.Token, info.ExpiresIn, err = util.RefreshTokenWithStore(srv.tokenStore, srv.tokenStoreKey, stale,
+			func() (string, int64, error) {
+				info, err := srv.fetchToken()
+				return info.Token, info.ExpiresIn, err
+			})
+	} else {
+		info, err = srv.fetchToken()
+	}
+	if err != nil {
+		return
+	}
+
+	// 更新 tokenGet 信息
+	srv.tokenGet.LastTokenInfo = info
+	srv.tokenGet.LastTimestamp = timeNowUnix
+
+	// 更新缓存
+	srv.tokenCache.Lock()
+	srv.tokenCache.Token = info.Token
+	srv.tokenCache.ExpiresAt = time.Now().Add(time.Duration(info.ExpiresIn) * time.Second)
+	srv.tokenCache.Unlock()
+
+	token = info
+	return
+}
+
+// 从微信服务器获取 component_access_token.
+func (srv *DefaultAccessTokenServer) fetchToken() (token accessTokenInfo, err error) {
 	verifyTicket, err := srv.verifyTicketGetter.GetVerifyTicket(srv.appId)
 	if err != nil {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
 		return
 	}
 
@@ -188,33 +229,23 @@ func (srv *DefaultAccessTokenServer) getToken() (token accessTokenInfo, cached b
 	defer textBufferPool.Put(requestBuf)
 
 	if err = json.NewEncoder(requestBuf).Encode(&request); err != nil {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
 		return
 	}
 	requestBytes := requestBuf.Bytes()
 
-	url := "https://api.weixin.qq.com/cgi-bin/component/api_component_token"
+	url := srv.endpointURL("https://api.weixin.qq.com/cgi-bin/component/api_component_token")
 
 	mp.LogInfoln("[WECHAT_DEBUG] request url:", url)
 	mp.LogInfoln("[WECHAT_DEBUG] request json:", string(requestBytes))
 
 	httpResp, err := srv.httpClient.Post(url, "application/json; charset=utf-8", bytes.NewReader(requestBytes))
 	if err != nil {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
 		return
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
-		err = fmt.Errorf("http.Status: %s", httpResp.Status)
+		err = &util.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 		return
 	}
 
@@ -225,26 +256,16 @@ func (srv *DefaultAccessTokenServer) getToken() (token accessTokenInfo, cached b
 
 	respBody, err := ioutil.ReadAll(httpResp.Body)
 	if err != nil {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
 		return
 	}
 
 	mp.LogInfoln("[WECHAT_DEBUG] response json:", string(respBody))
 
 	if err = json.Unmarshal(respBody, &result); err != nil {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
 		return
 	}
 
 	if result.ErrCode != mp.ErrCodeOK {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
 		err = &result.Error
 		return
 	}
@@ -252,10 +273,6 @@ func (srv *DefaultAccessTokenServer) getToken() (token accessTokenInfo, cached b
 	// 由于网络的延时, component_access_token 过期时间留了一个缓冲区
 	switch {
 	case result.ExpiresIn > 31556952: // 60*60*24*365.2425
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
 		err = errors.New("expires_in too large: " + strconv.FormatInt(result.ExpiresIn, 10))
 		return
 	case result.ExpiresIn > 60*60:
@@ -267,23 +284,10 @@ func (srv *DefaultAccessTokenServer) getToken() (token accessTokenInfo, cached b
 	case result.ExpiresIn > 60:
 		result.ExpiresIn -= 10
 	default:
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
 		err = errors.New("expires_in too small: " + strconv.FormatInt(result.ExpiresIn, 10))
 		return
 	}
 
-	// 更新 tokenGet 信息
-	srv.tokenGet.LastTokenInfo = result.accessTokenInfo
-	srv.tokenGet.LastTimestamp = timeNowUnix
-
-	// 更新缓存
-	srv.tokenCache.Lock()
-	srv.tokenCache.Token = result.accessTokenInfo.Token
-	srv.tokenCache.Unlock()
-
 	token = result.accessTokenInfo
 	return
 }
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/c77cc/wechat/mp"
+	"github.com/c77cc/wechat/util"
 )
 
 var _ mp.AccessTokenServer = (*DefaultAuthorizerAccessTokenServer)(nil)
@@ -25,7 +26,7 @@ type DefaultAuthorizerAccessTokenServer struct {
 	client          *Client
 	authorizerAppId string
 
-	resetTickerChan chan time.Duration // 用于重置 tokenDaemon 里的 ticker
+	refresher *util.RefreshScheduler // 定时刷新 authorizer_access_token
 
 	tokenGet struct {
 		sync.Mutex
@@ -37,6 +38,7 @@ type DefaultAuthorizerAccessTokenServer struct {
 		sync.RWMutex
 		Token        string
 		RefreshToken string // 最新的 authorizer_refresh_token
+		ExpiresAt    time.Time
 	}
 }
 
@@ -49,11 +51,10 @@ func NewDefaultAuthorizerAccessTokenServer(clt *Client, authorizerAppId, authori
 	srv = &DefaultAuthorizerAccessTokenServer{
 		client:          clt,
 		authorizerAppId: authorizerAppId,
-		resetTickerChan: make(chan time.Duration),
 	}
 	srv.tokenCache.RefreshToken = authorizerRefreshToken
 
-	go srv.tokenDaemon(time.Hour * 24) // 启动 tokenDaemon
+	srv.refresher = util.NewRefreshScheduler("component/authorizer_access_token/"+clt.AppId+"/"+authorizerAppId, srv.refresh)
 	return
 }
 
@@ -61,9 +62,11 @@ func NewDefaultAuthorizerAccessTokenServer(clt *Client, authorizerAppId, authori
 func (srv *DefaultAuthorizerAccessTokenServer) Token() (token string, err error) {
 	srv.tokenCache.RLock()
 	token = srv.tokenCache.Token
+	expiresAt := srv.tokenCache.ExpiresAt
 	srv.tokenCache.RUnlock()
 
-	if token != "" {
+	// 刷新失败的时候继续使用上一次获取的 authorizer_access_token, 直到过期
+	if token != "" && time.Now().Before(expiresAt) {
 		return
 	}
 	return srv.TokenRefresh()
@@ -72,41 +75,32 @@ func (srv *DefaultAuthorizerAccessTokenServer) Token() (token string, err error)
 // 刷新 authorizer_access_token
 func (srv *DefaultAuthorizerAccessTokenServer) TokenRefresh() (token string, err error) {
 	AccessTokenInfo, cached, err := srv.getToken()
+	if !cached {
+		srv.refresher.Refreshed(time.Duration(AccessTokenInfo.ExpiresIn)*time.Second, err)
+	}
 	if err != nil {
 		return
 	}
-	if !cached {
-		srv.resetTickerChan <- time.Duration(AccessTokenInfo.ExpiresIn) * time.Second
-	}
 	token = AccessTokenInfo.Token
 	return
 }
 
-func (srv *DefaultAuthorizerAccessTokenServer) tokenDaemon(tickDuration time.Duration) {
-NEW_TICK_DURATION:
-	ticker := time.NewTicker(tickDuration)
-
-	for {
-		select {
-		case tickDuration = <-srv.resetTickerChan:
-			ticker.Stop()
-			goto NEW_TICK_DURATION
-
-		case <-ticker.C:
-			AccessTokenInfo, cached, err := srv.getToken()
-			if err != nil {
-				break
-			}
-			if !cached {
-				newTickDuration := time.Duration(AccessTokenInfo.ExpiresIn) * time.Second
-				if tickDuration != newTickDuration {
-					tickDuration = newTickDuration
-					ticker.Stop()
-					goto NEW_TICK_DURATION
-				}
-			}
-		}
-	}
+// 停止定时刷新 authorizer_access_token 的后台 goroutine, 等待其退出后返回, 可以重复调用.
+//  Close 之后 Token 和 TokenRefresh 仍然可用, 只是不再定时刷新.
+func (srv *DefaultAuthorizerAccessTokenServer) Close() error {
+	srv.refresher.Close()
+	return nil
+}
+
+// 设置定时刷新 authorizer_access_token 的事件回调, hook == nil 的时候使用 util.DefaultRefreshHook.
+func (srv *DefaultAuthorizerAccessTokenServer) SetRefreshHook(hook util.RefreshHookFunc) {
+	srv.refresher.SetHook(hook)
+}
+
+// 由 refresher 定时调用.
+func (srv *DefaultAuthorizerAccessTokenServer) refresh() (expiresIn time.Duration, err error) {
+	info, _, err := srv.getToken()
+	return time.Duration(info.ExpiresIn) * time.Second, err
 }
 
 type AuthorizerAccessTokenInfo struct {
@@ -152,17 +146,10 @@ func (srv *DefaultAuthorizerAccessTokenServer) getToken() (token AuthorizerAcces
 
 	incompleteURL := "https:// api.weixin.qq.com /cgi-bin/component/api_authorizer_token?component_access_token="
 	if err = srv.client.PostJSON(incompleteURL, &request, &result); err != nil {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
 		return
 	}
 
 	if result.ErrCode != mp.ErrCodeOK {
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
 		err = &result.Error
 		return
 	}
@@ -170,10 +157,6 @@ func (srv *DefaultAuthorizerAccessTokenServer) getToken() (token AuthorizerAcces
 	// 由于网络的延时, authorizer_access_token 过期时间留了一个缓冲区
 	switch {
 	case result.ExpiresIn > 31556952: // 60*60*24*365.2425
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
 		err = errors.New("expires_in too large: " + strconv.FormatInt(result.ExpiresIn, 10))
 		return
 	case result.ExpiresIn > 60*60:
@@ -185,10 +168,6 @@ func (srv *DefaultAuthorizerAccessTokenServer) getToken() (token AuthorizerAcces
 	case result.ExpiresIn > 60:
 		result.ExpiresIn -= 10
 	default:
-		srv.tokenCache.Lock()
-		srv.tokenCache.Token = ""
-		srv.tokenCache.Unlock()
-
 		err = errors.New("expires_in too small: " + strconv.FormatInt(result.ExpiresIn, 10))
 		return
 	}
@@ -207,6 +186,7 @@ func (srv *DefaultAuthorizerAccessTokenServer) getToken() (token AuthorizerAcces
 	// 更新缓存
 	srv.tokenCache.Lock()
 	srv.tokenCache.Token = result.AuthorizerAccessTokenInfo.Token
+	srv.tokenCache.ExpiresAt = time.Now().Add(time.Duration(result.AuthorizerAccessTokenInfo.ExpiresIn) * time.Second)
 	srv.tokenCache.RefreshToken = result.AuthorizerAccessTokenInfo.RefreshToken
 	srv.tokenCache.Unlock()
 
@@ -89,7 +89,7 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, queryValues url.Values, s
 		// 验证签名
 		msgSignature2 := util.MsgSign(token, timestampStr, nonce, requestHttpBody.EncryptedMsg)
 		if subtle.ConstantTimeCompare([]byte(msgSignature1), []byte(msgSignature2)) != 1 {
-			err = fmt.Errorf("check signature failed, input: %s, local: %s", msgSignature1, msgSignature2)
+			err = &util.SignatureError{Name: "msg_signature", Input: msgSignature1, Local: msgSignature2}
 			irh.ServeInvalidRequest(w, r, err)
 			return
 		}
@@ -169,7 +169,7 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, queryValues url.Values, s
 
 		signature2 := util.Sign(srv.Token(), timestamp, nonce)
 		if subtle.ConstantTimeCompare([]byte(signature1), []byte(signature2)) != 1 {
-			err = fmt.Errorf("check signature failed, input: %s, local: %s", signature1, signature2)
+			err = &util.SignatureError{Name: "signature", Input: signature1, Local: signature2}
 			irh.ServeInvalidRequest(w, r, err)
 			return
 		}
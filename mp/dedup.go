@@ -0,0 +1,75 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mp
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/c77cc/wechat/util"
+)
+
+const (
+	DefaultDedupTTL       = time.Minute // 微信服务器 5 秒内收不到响应会重试, 最多重试 3 次
+	DefaultDedupStoreSize = 10000       // 默认的 MemoryDedupStore 的容量
+)
+
+var _ MessageHandler = (*DedupMessageHandler)(nil)
+
+// DedupMessageHandler 过滤微信服务器重试推送的消息(事件), 保证同一条消息在 ttl 时间内只交给 handler 处理一次.
+//  消息用 MsgId 去重, 事件(没有 MsgId)用 FromUserName + CreateTime + Event 去重;
+//  重复的消息直接回复空串, 微信服务器不会再重试.
+//
+//  handler := mp.NewDedupMessageHandler(messageServeMux, nil, 0)
+//  wechatServer := mp.NewDefaultWechatServer(oriId, token, appId, AESKey, handler)
+type DedupMessageHandler struct {
+	handler MessageHandler
+	store   util.DedupStore
+	ttl     time.Duration
+}
+
+// 创建一个新的 DedupMessageHandler.
+//  如果 store == nil 则使用容量为 DefaultDedupStoreSize 的 util.MemoryDedupStore;
+//  如果 ttl <= 0 则使用 DefaultDedupTTL.
+func NewDedupMessageHandler(handler MessageHandler, store util.DedupStore, ttl time.Duration) *DedupMessageHandler {
+	if handler == nil {
+		panic("nil MessageHandler")
+	}
+	if store == nil {
+		store = util.NewMemoryDedupStore(DefaultDedupStoreSize)
+	}
+	if ttl <= 0 {
+		ttl = DefaultDedupTTL
+	}
+	return &DedupMessageHandler{
+		handler: handler,
+		store:   store,
+		ttl:     ttl,
+	}
+}
+
+func (h *DedupMessageHandler) ServeMessage(w http.ResponseWriter, r *Request) {
+	added, err := h.store.Add(dedupKey(r.MixedMsg), h.ttl)
+	if err != nil {
+		// 存储出错的时候宁可重复处理也不能丢消息
+		LogInfoln("[WECHAT_DEDUP] store error:", err)
+		h.handler.ServeMessage(w, r)
+		return
+	}
+	if !added {
+		return
+	}
+	h.handler.ServeMessage(w, r)
+}
+
+func dedupKey(msg *MixedMessage) string {
+	if msg.MsgId != 0 {
+		return "mp/" + msg.ToUserName + "/" + strconv.FormatInt(msg.MsgId, 10)
+	}
+	return "mp/" + msg.ToUserName + "/" + msg.FromUserName + "/" +
+		strconv.FormatInt(msg.CreateTime, 10) + "/" + msg.Event
+}
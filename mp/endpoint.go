@@ -0,0 +1,35 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mp
+
+import (
+	"github.com/c77cc/wechat/util"
+)
+
+// 默认的 api 地址配置, 所有没有指定 Endpoint 的 WechatClient 和 DefaultAccessTokenServer 都使用这个配置.
+//  沒有加锁, 请确保在初始化阶段修改!
+var DefaultEndpoint = &util.Endpoint{}
+
+// 把官方的 api 地址 rawURL 转换为 clt 配置的地址, 见 util.Endpoint.
+func (clt *WechatClient) EndpointURL(rawURL string) string {
+	if clt.Endpoint != nil {
+		return clt.Endpoint.URL(rawURL)
+	}
+	return DefaultEndpoint.URL(rawURL)
+}
+
+// 设置 srv 获取 access_token 的 api 地址配置, 如果 endpoint == nil 则使用 DefaultEndpoint.
+//  沒有加锁, 请确保在初始化阶段调用!
+func (srv *DefaultAccessTokenServer) SetEndpoint(endpoint *util.Endpoint) {
+	srv.endpoint = endpoint
+}
+
+func (srv *DefaultAccessTokenServer) endpointURL(rawURL string) string {
+	if srv.endpoint != nil {
+		return srv.endpoint.URL(rawURL)
+	}
+	return DefaultEndpoint.URL(rawURL)
+}
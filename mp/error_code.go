@@ -0,0 +1,148 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mp
+
+import (
+	"github.com/c77cc/wechat/util"
+)
+
+type errCodeInfo struct {
+	text     string // 返回码的说明
+	category error  // 返回码的分类, 见 util.ErrInvalidCredential 等; nil 表示没有分类
+}
+
+// 公众平台全局返回码, 见 http://mp.weixin.qq.com/wiki/17/fa4e1434e57290788bde25603fa2fcbd.html
+var errCodeTable = map[int]errCodeInfo{
+	-1:    {"系统繁忙, 此时请开发者稍候再试", nil},
+	0:     {"请求成功", nil},
+	40001: {"获取 access_token 时 AppSecret 错误, 或者 access_token 无效", util.ErrInvalidCredential},
+	40002: {"不合法的凭证类型", util.ErrInvalidCredential},
+	40003: {"不合法的 OpenID", nil},
+	40004: {"不合法的媒体文件类型", nil},
+	40005: {"不合法的文件类型", nil},
+	40006: {"不合法的文件大小", nil},
+	40007: {"不合法的媒体文件 id", nil},
+	40008: {"不合法的消息类型", nil},
+	40009: {"不合法的图片文件大小", nil},
+	40010: {"不合法的语音文件大小", nil},
+	40011: {"不合法的视频文件大小", nil},
+	40012: {"不合法的缩略图文件大小", nil},
+	40013: {"不合法的 AppID", util.ErrInvalidCredential},
+	40014: {"不合法的 access_token", util.ErrInvalidCredential},
+	40015: {"不合法的菜单类型", nil},
+	40016: {"不合法的按钮个数", nil},
+	40017: {"不合法的按钮个数", nil},
+	40018: {"不合法的按钮名字长度", nil},
+	40019: {"不合法的按钮 KEY 长度", nil},
+	40020: {"不合法的按钮 URL 长度", nil},
+	40021: {"不合法的菜单版本号", nil},
+	40022: {"不合法的子菜单级数", nil},
+	40023: {"不合法的子菜单按钮个数", nil},
+	40024: {"不合法的子菜单按钮类型", nil},
+	40025: {"不合法的子菜单按钮名字长度", nil},
+	40026: {"不合法的子菜单按钮 KEY 长度", nil},
+	40027: {"不合法的子菜单按钮 URL 长度", nil},
+	40028: {"不合法的自定义菜单使用用户", nil},
+	40029: {"不合法的 oauth_code", nil},
+	40030: {"不合法的 refresh_token", nil},
+	40031: {"不合法的 openid 列表", nil},
+	40032: {"不合法的 openid 列表长度", nil},
+	40033: {"不合法的请求字符, 不能包含 \\uxxxx 格式的字符", nil},
+	40035: {"不合法的参数", nil},
+	40038: {"不合法的请求格式", nil},
+	40039: {"不合法的 URL 长度", nil},
+	40050: {"不合法的分组 id", nil},
+	40051: {"分组名字不合法", nil},
+	40117: {"分组名字不合法", nil},
+	40118: {"media_id 大小不合法", nil},
+	40119: {"button 类型错误", nil},
+	40120: {"button 类型错误", nil},
+	40121: {"不合法的 media_id 类型", nil},
+	40125: {"无效的 AppSecret", util.ErrInvalidCredential},
+	40132: {"微信号不合法", nil},
+	40137: {"不支持的图片格式", nil},
+	40164: {"调用接口的 IP 地址不在白名单中", nil},
+	41001: {"缺少 access_token 参数", util.ErrInvalidCredential},
+	41002: {"缺少 appid 参数", util.ErrInvalidCredential},
+	41003: {"缺少 refresh_token 参数", nil},
+	41004: {"缺少 secret 参数", util.ErrInvalidCredential},
+	41005: {"缺少多媒体文件数据", nil},
+	41006: {"缺少 media_id 参数", nil},
+	41007: {"缺少子菜单数据", nil},
+	41008: {"缺少 oauth code", nil},
+	41009: {"缺少 openid", nil},
+	42001: {"access_token 超时", util.ErrInvalidCredential},
+	42002: {"refresh_token 超时", nil},
+	42003: {"oauth_code 超时", nil},
+	42007: {"用户修改微信密码, access_token 和 refresh_token 失效, 需要重新授权", nil},
+	43001: {"需要 GET 请求", nil},
+	43002: {"需要 POST 请求", nil},
+	43003: {"需要 HTTPS 请求", nil},
+	43004: {"需要接收者关注", util.ErrNotSubscribed},
+	43005: {"需要好友关系", nil},
+	43019: {"需要将接收者从黑名单中移除", nil},
+	44001: {"多媒体文件为空", nil},
+	44002: {"POST 的数据包为空", nil},
+	44003: {"图文消息内容为空", nil},
+	44004: {"文本消息内容为空", nil},
+	45001: {"多媒体文件大小超过限制", nil},
+	45002: {"消息内容超过限制", nil},
+	45003: {"标题字段超过限制", nil},
+	45004: {"描述字段超过限制", nil},
+	45005: {"链接字段超过限制", nil},
+	45006: {"图片链接字段超过限制", nil},
+	45007: {"语音播放时间超过限制", nil},
+	45008: {"图文消息超过限制", nil},
+	45009: {"接口调用超过限制", util.ErrRateLimited},
+	45010: {"创建菜单个数超过限制", nil},
+	45011: {"API 调用太频繁, 请稍候再试", util.ErrRateLimited},
+	45015: {"回复时间超过限制", util.ErrResponseTimeout},
+	45016: {"系统分组, 不允许修改", nil},
+	45017: {"分组名字过长", nil},
+	45018: {"分组数量超过上限", nil},
+	45047: {"客服接口下行条数超过上限", nil},
+	46001: {"不存在媒体数据", nil},
+	46002: {"不存在的菜单版本", nil},
+	46003: {"不存在的菜单数据", nil},
+	46004: {"不存在的用户", nil},
+	47001: {"解析 JSON/XML 内容错误", nil},
+	48001: {"api 功能未授权", nil},
+	48004: {"api 接口被封禁", nil},
+	48005: {"api 禁止删除被自动回复和自定义菜单引用的素材", nil},
+	48006: {"api 禁止清零调用次数, 因为清零次数达到上限", nil},
+	50001: {"用户未授权该 api", nil},
+	50002: {"用户受限, 可能是违规后接口被封禁", nil},
+	61450: {"系统错误", nil},
+	61451: {"参数错误", nil},
+	61452: {"无效客服账号", nil},
+	61453: {"客服帐号已存在", nil},
+	61454: {"客服帐号名长度超过限制", nil},
+	61455: {"客服帐号名包含非法字符", nil},
+	61456: {"客服帐号个数超过限制", nil},
+	61457: {"无效头像文件类型", nil},
+	61500: {"日期格式错误", nil},
+	65301: {"不存在此 menuid 对应的个性化菜单", nil},
+	65302: {"没有相应的用户", nil},
+	65303: {"没有默认菜单, 不能创建个性化菜单", nil},
+	65304: {"MatchRule 信息为空", nil},
+	65305: {"个性化菜单数量受限", nil},
+	65306: {"不支持个性化菜单的帐号", nil},
+	65307: {"个性化菜单信息为空", nil},
+	65308: {"包含没有响应类型的 button", nil},
+	65309: {"个性化菜单开关处于关闭状态", nil},
+	65310: {"填写了省份或城市信息, 国家信息不能为空", nil},
+	65311: {"填写了城市信息, 省份信息不能为空", nil},
+	65312: {"不合法的国家信息", nil},
+	65313: {"不合法的省份信息", nil},
+	65314: {"不合法的城市信息", nil},
+	65316: {"该公众号的菜单设置了过多的域名外跳", nil},
+	65317: {"不合法的 URL", nil},
+}
+
+// 返回全局返回码 code 的说明, 未知的返回码返回 "".
+func ErrCodeText(code int) string {
+	return errCodeTable[code].text
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/c77cc/wechat/mp"
+	"github.com/c77cc/wechat/util"
 )
 
 // jsapi_ticket 中控服务器接口.
@@ -34,13 +35,18 @@ var _ TicketServer = (*DefaultTicketServer)(nil)
 
 // TicketServer 的简单实现.
 //  NOTE:
-//  1. 用于单进程环境.
+//  1. 不指定 TokenStore 的时候用于单进程环境;
 //  2. 因为 DefaultTicketServer 同时也是一个简单的中控服务器, 而不是仅仅实现 TicketServer 接口,
-//     所以整个系统只能存在一个 DefaultTicketServer 实例!
+//     所以不指定 TokenStore 的时候整个系统只能存在一个 DefaultTicketServer 实例!
+//  3. 多进程环境下请用 NewDefaultTicketServerWithStore 创建, 所有进程共享同一个 TokenStore,
+//     同一时刻只有一个进程去微信服务器刷新 jsapi_ticket.
 type DefaultTicketServer struct {
 	wechatClient mp.WechatClient
 
-	resetTickerChan chan time.Duration // 用于重置 ticketDaemon 里的 ticker
+	ticketStore    util.TokenStore // 多进程共享 jsapi_ticket 的存储, 可以为 nil
+	ticketStoreKey string
+
+	refresher *util.RefreshScheduler // 定时刷新 jsapi_ticket
 
 	ticketGet struct {
 		sync.Mutex
@@ -50,13 +56,24 @@ type DefaultTicketServer struct {
 
 	ticketCache struct {
 		sync.RWMutex
-		Ticket string
+		Ticket    string
+		ExpiresAt time.Time
 	}
 }
 
 // 创建一个新的 DefaultTicketServer.
 //  如果 httpClient == nil 则默认使用 http.DefaultClient.
 func NewDefaultTicketServer(AccessTokenServer mp.AccessTokenServer, httpClient *http.Client) (srv *DefaultTicketServer) {
+	return NewDefaultTicketServerWithStore(AccessTokenServer, "", nil, httpClient)
+}
+
+// 创建一个新的 DefaultTicketServer, 通过 store 和其他进程共享 jsapi_ticket.
+//  appId 是 AccessTokenServer 对应的公众号的 appid, 用于区分 store 里不同公众号的 jsapi_ticket;
+//  如果 store == nil 则等价于 NewDefaultTicketServer;
+//  如果 httpClient == nil 则默认使用 http.DefaultClient.
+func NewDefaultTicketServerWithStore(AccessTokenServer mp.AccessTokenServer, appId string, store util.TokenStore,
+	httpClient *http.Client) (srv *DefaultTicketServer) {
+
 	if AccessTokenServer == nil {
 		panic("nil AccessTokenServer")
 	}
@@ -69,19 +86,22 @@ func NewDefaultTicketServer(AccessTokenServer mp.AccessTokenServer, httpClient *
 			AccessTokenServer: AccessTokenServer,
 			HttpClient:        httpClient,
 		},
-		resetTickerChan: make(chan time.Duration),
+		ticketStore:    store,
+		ticketStoreKey: "mp/jsapi_ticket/" + appId,
 	}
 
-	go srv.ticketDaemon(time.Hour * 24) // 启动 tokenDaemon
+	srv.refresher = util.NewRefreshScheduler(srv.ticketStoreKey, srv.refresh)
 	return
 }
 
 func (srv *DefaultTicketServer) Ticket() (ticket string, err error) {
 	srv.ticketCache.RLock()
 	ticket = srv.ticketCache.Ticket
+	expiresAt := srv.ticketCache.ExpiresAt
 	srv.ticketCache.RUnlock()
 
-	if ticket != "" {
+	// 刷新失败的时候继续使用上一次获取的 jsapi_ticket, 直到过期
+	if ticket != "" && time.Now().Before(expiresAt) {
 		return
 	}
 	return srv.TicketRefresh()
@@ -89,41 +109,32 @@ func (srv *DefaultTicketServer) Ticket() (ticket string, err error) {
 
 func (srv *DefaultTicketServer) TicketRefresh() (ticket string, err error) {
 	ticketInfo, cached, err := srv.getTicket()
+	if !cached {
+		srv.refresher.Refreshed(time.Duration(ticketInfo.ExpiresIn)*time.Second, err)
+	}
 	if err != nil {
 		return
 	}
-	if !cached {
-		srv.resetTickerChan <- time.Duration(ticketInfo.ExpiresIn) * time.Second
-	}
 	ticket = ticketInfo.Ticket
 	return
 }
 
-func (srv *DefaultTicketServer) ticketDaemon(tickDuration time.Duration) {
-NEW_TICK_DURATION:
-	ticker := time.NewTicker(tickDuration)
-
-	for {
-		select {
-		case tickDuration = <-srv.resetTickerChan:
-			ticker.Stop()
-			goto NEW_TICK_DURATION
-
-		case <-ticker.C:
-			ticketInfo, cached, err := srv.getTicket()
-			if err != nil {
-				break
-			}
-			if !cached {
-				newTickDuration := time.Duration(ticketInfo.ExpiresIn) * time.Second
-				if tickDuration != newTickDuration {
-					tickDuration = newTickDuration
-					ticker.Stop()
-					goto NEW_TICK_DURATION
-				}
-			}
-		}
-	}
+// 停止定时刷新 jsapi_ticket 的后台 goroutine, 等待其退出后返回, 可以重复调用.
+//  Close 之后 Ticket 和 TicketRefresh 仍然可用, 只是不再定时刷新.
+func (srv *DefaultTicketServer) Close() error {
+	srv.refresher.Close()
+	return nil
+}
+
+// 设置定时刷新 jsapi_ticket 的事件回调, hook == nil 的时候使用 util.DefaultRefreshHook.
+func (srv *DefaultTicketServer) SetRefreshHook(hook util.RefreshHookFunc) {
+	srv.refresher.SetHook(hook)
+}
+
+// 由 refresher 定时调用.
+func (srv *DefaultTicketServer) refresh() (expiresIn time.Duration, err error) {
+	info, _, err := srv.getTicket()
+	return time.Duration(info.ExpiresIn) * time.Second, err
 }
 
 type ticketInfo struct {
@@ -150,6 +161,38 @@ func (srv *DefaultTicketServer) getTicket() (ticket ticketInfo, cached bool, err
 		return
 	}
 
+	var info ticketInfo
+	if srv.ticketStore != nil {
+		srv.ticketCache.RLock()
+		stale := srv.ticketCache.Ticket
+		srv.ticketCache.RUnlock()
+
+		info.Ticket, info.ExpiresIn, err = util.RefreshTokenWithStore(srv.ticketStore, srv.ticketStoreKey, stale,
+			func() (string, int64, error) {
+				info, err := srv.fetchTicket()
+				return info.Ticket, info.ExpiresIn, err
+			})
+	} else {
+		info, err = srv.fetchTicket()
+	}
+	if err != nil {
+		return
+	}
+
+	srv.ticketGet.LastTicketInfo = info
+	srv.ticketGet.LastTimestamp = timeNowUnix
+
+	srv.ticketCache.Lock()
+	srv.ticketCache.Ticket = info.Ticket
+	srv.ticketCache.ExpiresAt = time.Now().Add(time.Duration(info.ExpiresIn) * time.Second)
+	srv.ticketCache.Unlock()
+
+	ticket = info
+	return
+}
+
+// 从微信服务器获取 jsapi_ticket.
+func (srv *DefaultTicketServer) fetchTicket() (ticket ticketInfo, err error) {
 	var result struct {
 		mp.Error
 		ticketInfo
@@ -157,17 +200,10 @@ func (srv *DefaultTicketServer) getTicket() (ticket ticketInfo, cached bool, err
 
 	incompleteURL := "https://api.weixin.qq.com/cgi-bin/ticket/getticket?type=jsapi&access_token="
 	if err = srv.wechatClient.GetJSON(incompleteURL, &result); err != nil {
-		srv.ticketCache.Lock()
-		srv.ticketCache.Ticket = ""
-		srv.ticketCache.Unlock()
 		return
 	}
 
 	if result.ErrCode != mp.ErrCodeOK {
-		srv.ticketCache.Lock()
-		srv.ticketCache.Ticket = ""
-		srv.ticketCache.Unlock()
-
 		err = &result.Error
 		return
 	}
@@ -175,10 +211,6 @@ func (srv *DefaultTicketServer) getTicket() (ticket ticketInfo, cached bool, err
 	// 由于网络的延时, jsapi_ticket 过期时间留了一个缓冲区
 	switch {
 	case result.ExpiresIn > 31556952: // 60*60*24*365.2425
-		srv.ticketCache.Lock()
-		srv.ticketCache.Ticket = ""
-		srv.ticketCache.Unlock()
-
 		err = errors.New("expires_in too large: " + strconv.FormatInt(result.ExpiresIn, 10))
 		return
 	case result.ExpiresIn > 60*60:
@@ -190,21 +222,10 @@ func (srv *DefaultTicketServer) getTicket() (ticket ticketInfo, cached bool, err
 	case result.ExpiresIn > 60:
 		result.ExpiresIn -= 10
 	default:
-		srv.ticketCache.Lock()
-		srv.ticketCache.Ticket = ""
-		srv.ticketCache.Unlock()
-
 		err = errors.New("expires_in too small: " + strconv.FormatInt(result.ExpiresIn, 10))
 		return
 	}
 
-	srv.ticketGet.LastTicketInfo = result.ticketInfo
-	srv.ticketGet.LastTimestamp = timeNowUnix
-
-	srv.ticketCache.Lock()
-	srv.ticketCache.Ticket = result.ticketInfo.Ticket
-	srv.ticketCache.Unlock()
-
 	ticket = result.ticketInfo
 	return
 }
@@ -9,13 +9,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 
 	"github.com/c77cc/wechat/mp"
+	"github.com/c77cc/wechat/util"
 )
 
 // 下载多媒体到文件.
@@ -67,16 +67,16 @@ func (clt Client) downloadMaterialToWriter(mediaId string, writer io.Writer) (er
 
 	hasRetried := false
 RETRY:
-	finalURL := "https://api.weixin.qq.com/cgi-bin/material/get_material?access_token=" + url.QueryEscape(token)
+	finalURL := clt.EndpointURL("https://api.weixin.qq.com/cgi-bin/material/get_material?access_token=") + url.QueryEscape(token)
 
-	httpResp, err := clt.HttpClient.Post(finalURL, "application/json; charset=utf-8", bytes.NewReader(requestBody))
+	httpResp, err := util.HttpPostContext(clt.Context(), clt.HttpClient, finalURL, "application/json; charset=utf-8", bytes.NewReader(requestBody))
 	if err != nil {
 		return
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("http.Status: %s", httpResp.Status)
+		return &util.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 	}
 
 	// fuck, 騰訊這次又蛋疼了, Content-Type 不能區分返回的是媒體類型還是錯誤
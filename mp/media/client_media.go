@@ -16,6 +16,7 @@ import (
 	"os"
 
 	"github.com/c77cc/wechat/mp"
+	"github.com/c77cc/wechat/util"
 )
 
 // 下载多媒体到文件.
@@ -53,17 +54,17 @@ func (clt Client) downloadMediaToWriter(mediaId string, writer io.Writer) (err e
 
 	hasRetried := false
 RETRY:
-	finalURL := "https://api.weixin.qq.com/cgi-bin/media/get?media_id=" + url.QueryEscape(mediaId) +
+	finalURL := clt.EndpointURL("https://api.weixin.qq.com/cgi-bin/media/get?media_id=") + url.QueryEscape(mediaId) +
 		"&access_token=" + url.QueryEscape(token)
 
-	httpResp, err := clt.HttpClient.Get(finalURL)
+	httpResp, err := util.HttpGetContext(clt.Context(), clt.HttpClient, finalURL)
 	if err != nil {
 		return
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("http.Status: %s", httpResp.Status)
+		return &util.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 	}
 
 	ContentType, _, _ := mime.ParseMediaType(httpResp.Header.Get("Content-Type"))
@@ -0,0 +1,21 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+// 异步回复消息.
+//  处理消息比较慢的时候, 在 budget 时间内得到回复的话以被动回复的方式回复用户,
+//  否则先回复微信服务器 "success", 得到回复后再通过客服消息接口发送给用户.
+//
+//  handler := async.NewMessageHandler(async.HandlerFunc(func(r *mp.Request) *async.Future {
+//      msg := request.GetText(r.MixedMsg)
+//      return async.Go(func() (interface{}, error) {
+//          content, err := slowBackend(msg.Content)
+//          if err != nil {
+//              return nil, err
+//          }
+//          return response.NewText(msg.FromUserName, msg.ToUserName, msg.CreateTime, content), nil
+//      })
+//  }), wechatClient, 0)
+//  messageServeMux.MessageHandle(request.MsgTypeText, handler)
+package async
@@ -0,0 +1,55 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package async
+
+import (
+	"sync"
+)
+
+// 异步计算的回复消息.
+type Future struct {
+	once  sync.Once
+	done  chan struct{}
+	reply interface{}
+	err   error
+}
+
+func NewFuture() *Future {
+	return &Future{
+		done: make(chan struct{}),
+	}
+}
+
+// 在新的 goroutine 里执行 fn, 返回 fn 结果的 Future.
+func Go(fn func() (reply interface{}, err error)) *Future {
+	f := NewFuture()
+	go func() {
+		reply, err := fn()
+		f.Resolve(reply, err)
+	}()
+	return f
+}
+
+// 设置 Future 的结果, 只有第一次调用有效.
+//  reply 是 response 包里的被动回复消息, 比如 *response.Text; reply == nil 表示不需要回复.
+func (f *Future) Resolve(reply interface{}, err error) {
+	f.once.Do(func() {
+		f.reply = reply
+		f.err = err
+		close(f.done)
+	})
+}
+
+// 返回一个 channel, Future 得到结果后该 channel 被关闭.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// 等待并返回 Future 的结果.
+func (f *Future) Get() (reply interface{}, err error) {
+	<-f.done
+	return f.reply, f.err
+}
@@ -0,0 +1,176 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package async
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/c77cc/wechat/mp"
+	"github.com/c77cc/wechat/mp/message/custom"
+	"github.com/c77cc/wechat/mp/message/response"
+)
+
+const (
+	// 微信服务器 5 秒内收不到响应就会断开并重试, 默认留 1 秒的余量.
+	DefaultBudget = time.Second * 4
+
+	// 超过 budget 之后继续等待 Future 的时间, 超时后放弃发送客服消息.
+	DefaultFallbackTimeout = time.Minute * 5
+)
+
+// 异步处理消息(事件)的接口.
+//  r.HttpRequest 在 ServeMessage 返回后就失效了, 后台计算不要再使用它.
+type Handler interface {
+	ServeMessageAsync(r *mp.Request) *Future
+}
+
+type HandlerFunc func(*mp.Request) *Future
+
+func (fn HandlerFunc) ServeMessageAsync(r *mp.Request) *Future {
+	return fn(r)
+}
+
+var _ mp.MessageHandler = (*MessageHandler)(nil)
+
+// MessageHandler 把 Handler 适配为 mp.MessageHandler.
+//  Future 在 budget 时间内得到结果的话用 mp.WriteRawResponse 或者 mp.WriteAESResponse 被动回复,
+//  否则立即回复 "success", 得到结果后通过 custom.Client 发送给消息的 FromUserName;
+//  如果超过 fallbackTimeout 还没有得到结果则放弃, 避免一直没有结果的 Future 泄漏 goroutine.
+type MessageHandler struct {
+	handler         Handler
+	client          custom.Client
+	budget          time.Duration
+	fallbackTimeout time.Duration
+}
+
+// 创建一个新的 MessageHandler, budget <= 0 则使用 DefaultBudget.
+func NewMessageHandler(handler Handler, clt *mp.WechatClient, budget time.Duration) *MessageHandler {
+	if handler == nil {
+		panic("nil Handler")
+	}
+	if clt == nil {
+		panic("nil WechatClient")
+	}
+	if budget <= 0 {
+		budget = DefaultBudget
+	}
+	return &MessageHandler{
+		handler:         handler,
+		client:          custom.Client{WechatClient: clt},
+		budget:          budget,
+		fallbackTimeout: DefaultFallbackTimeout,
+	}
+}
+
+// 设置超过 budget 之后继续等待 Future 的时间, timeout <= 0 则使用 DefaultFallbackTimeout.
+//  没有加锁, 请在开始处理消息之前设置.
+func (h *MessageHandler) SetFallbackTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultFallbackTimeout
+	}
+	h.fallbackTimeout = timeout
+}
+
+func (h *MessageHandler) ServeMessage(w http.ResponseWriter, r *mp.Request) {
+	future := h.handler.ServeMessageAsync(r)
+	if future == nil {
+		io.WriteString(w, "success")
+		return
+	}
+
+	timer := time.NewTimer(h.budget)
+	defer timer.Stop()
+
+	select {
+	case <-future.Done():
+		reply, err := future.Get()
+		if err != nil {
+			mp.LogInfoln("[WECHAT_ASYNC] handler error:", err)
+			io.WriteString(w, "success")
+			return
+		}
+		if reply == nil {
+			io.WriteString(w, "success")
+			return
+		}
+		if r.EncryptType == "aes" {
+			err = mp.WriteAESResponse(w, r, reply)
+		} else {
+			err = mp.WriteRawResponse(w, r, reply)
+		}
+		if err != nil {
+			mp.LogInfoln("[WECHAT_ASYNC] write response error:", err)
+		}
+
+	case <-timer.C:
+		io.WriteString(w, "success")
+
+		toUser := r.MixedMsg.FromUserName
+		fallbackTimeout := h.fallbackTimeout
+		go func() {
+			timer := time.NewTimer(fallbackTimeout)
+			defer timer.Stop()
+
+			select {
+			case <-future.Done():
+			case <-timer.C:
+				mp.LogInfoln("[WECHAT_ASYNC] timeout waiting for reply, touser:", toUser)
+				return
+			}
+			reply, err := future.Get()
+			if err != nil {
+				mp.LogInfoln("[WECHAT_ASYNC] handler error:", err)
+				return
+			}
+			if reply == nil {
+				return
+			}
+			if err = h.sendCustom(toUser, reply); err != nil {
+				mp.LogInfoln("[WECHAT_ASYNC] send custom message error:", err)
+			}
+		}()
+	}
+}
+
+// 把被动回复消息转换为客服消息发送给 toUser.
+func (h *MessageHandler) sendCustom(toUser string, reply interface{}) (err error) {
+	switch msg := reply.(type) {
+	case *response.Text:
+		return h.client.SendText(custom.NewText(toUser, msg.Content, ""))
+
+	case *response.Image:
+		return h.client.SendImage(custom.NewImage(toUser, msg.Image.MediaId, ""))
+
+	case *response.Voice:
+		return h.client.SendVoice(custom.NewVoice(toUser, msg.Voice.MediaId, ""))
+
+	case *response.Video:
+		return h.client.SendVideo(custom.NewVideo(toUser, msg.Video.MediaId, "",
+			msg.Video.Title, msg.Video.Description, ""))
+
+	case *response.Music:
+		return h.client.SendMusic(custom.NewMusic(toUser, msg.Music.ThumbMediaId, msg.Music.MusicURL,
+			msg.Music.HQMusicURL, msg.Music.Title, msg.Music.Description, ""))
+
+	case *response.News:
+		articles := make([]custom.Article, len(msg.Articles))
+		for i, article := range msg.Articles {
+			articles[i] = custom.Article{
+				Title:       article.Title,
+				Description: article.Description,
+				URL:         article.URL,
+				PicURL:      article.PicURL,
+			}
+		}
+		return h.client.SendNews(custom.NewNews(toUser, articles, ""))
+
+	default:
+		return fmt.Errorf("unsupported reply type for custom message: %T", reply)
+	}
+}
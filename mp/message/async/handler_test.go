@@ -0,0 +1,119 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package async
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/c77cc/wechat/mp"
+	"github.com/c77cc/wechat/mp/message/request"
+	"github.com/c77cc/wechat/mp/message/response"
+	"github.com/c77cc/wechat/mp/mptest"
+)
+
+func TestMessageHandler(t *testing.T) {
+	srv := mptest.NewServer("appid", "appsecret")
+	defer srv.Close()
+
+	tokenServer := mp.NewDefaultAccessTokenServer("appid", "appsecret", nil)
+	defer tokenServer.Close()
+	tokenServer.SetEndpoint(srv.Endpoint())
+	clt := mp.NewWechatClient(tokenServer, nil)
+	clt.Endpoint = srv.Endpoint()
+
+	// delay 为处理消息需要的时间, 返回的 chan 在处理完成后关闭
+	newCallback := func(delay, budget, fallbackTimeout time.Duration) (cb func() (*response.Text, error), done chan struct{}) {
+		done = make(chan struct{})
+		handler := NewMessageHandler(HandlerFunc(func(r *mp.Request) *Future {
+			msg := request.GetText(r.MixedMsg)
+			return Go(func() (interface{}, error) {
+				defer close(done)
+				time.Sleep(delay)
+				return response.NewText(msg.FromUserName, msg.ToUserName, msg.CreateTime, "echo: "+msg.Content), nil
+			})
+		}), clt, budget)
+		handler.SetFallbackTimeout(fallbackTimeout)
+
+		wechatServer := mp.NewDefaultWechatServer("gh_oriid", "token", "appid", bytes.Repeat([]byte("k"), 32), handler)
+		callback := mptest.NewCallback(wechatServer, mptest.ModeRaw)
+		frontend := mp.NewWechatServerFrontend(wechatServer, nil)
+		cb = func() (*response.Text, error) {
+			var reply response.Text
+			err := callback.Do(frontend, &request.Text{
+				CommonMessageHeader: mp.CommonMessageHeader{
+					ToUserName:   "gh_oriid",
+					FromUserName: "openid",
+					CreateTime:   time.Now().Unix(),
+					MsgType:      request.MsgTypeText,
+				},
+				Content: "hello",
+			}, &reply)
+			return &reply, err
+		}
+		return
+	}
+
+	// budget 内得到结果, 被动回复
+	cb, _ := newCallback(0, time.Second, time.Second)
+	reply, err := cb()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if reply.ToUserName != "openid" || reply.Content != "echo: hello" {
+		t.Errorf("unexpected reply: %+v", reply)
+		return
+	}
+	if n := len(srv.Messages()); n != 0 {
+		t.Errorf("%d custom messages sent, want 0", n)
+		return
+	}
+
+	// 超过 budget, 通过客服消息发送
+	cb, _ = newCallback(time.Millisecond*50, time.Millisecond*10, time.Second)
+	if _, err = cb(); err != mptest.ErrNoReply {
+		t.Errorf("got %v, want ErrNoReply", err)
+		return
+	}
+	var messages []mptest.Message
+	for i := 0; i < 100 && len(messages) == 0; i++ {
+		time.Sleep(time.Millisecond * 10)
+		messages = srv.Messages()
+	}
+	if len(messages) != 1 || messages[0].Path != "/cgi-bin/message/custom/send" {
+		t.Errorf("unexpected messages: %v", messages)
+		return
+	}
+	var text struct {
+		ToUser string `json:"touser"`
+		Text   struct {
+			Content string `json:"content"`
+		} `json:"text"`
+	}
+	if err = json.Unmarshal(messages[0].Body, &text); err != nil {
+		t.Error(err)
+		return
+	}
+	if text.ToUser != "openid" || text.Text.Content != "echo: hello" {
+		t.Errorf("unexpected custom message: %s", messages[0].Body)
+		return
+	}
+
+	// 超过 fallbackTimeout 就不再发送
+	cb, done := newCallback(time.Millisecond*100, time.Millisecond*10, time.Millisecond*20)
+	if _, err = cb(); err != mptest.ErrNoReply {
+		t.Errorf("got %v, want ErrNoReply", err)
+		return
+	}
+	<-done
+	time.Sleep(time.Millisecond * 50)
+	if n := len(srv.Messages()); n != 1 {
+		t.Errorf("%d custom messages sent after fallback timeout, want 1", n)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 
 	"github.com/c77cc/wechat/mp"
+	"github.com/c77cc/wechat/util"
 )
 
 type Client struct {
@@ -70,7 +71,7 @@ func (clt Client) send(msg interface{}) (msgid int64, err error) {
 	}
 
 	incompleteURL := "https://api.weixin.qq.com/cgi-bin/message/mass/sendall?access_token="
-	if err = clt.PostJSON(incompleteURL, msg, &result); err != nil {
+	if err = clt.PostJSONContext(util.NonIdempotent(clt.Context()), incompleteURL, msg, &result); err != nil {
 		return
 	}
 
@@ -0,0 +1,233 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mptest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/c77cc/wechat/mp"
+	"github.com/c77cc/wechat/util"
+)
+
+// 回调消息的加密方式
+const (
+	ModeRaw        = iota // 明文模式
+	ModeCompatible        // 兼容模式, 消息体同时包含明文和密文
+	ModeAES               // 安全模式
+)
+
+// 回调处理没有回复消息(回复了空串或者 "success").
+var ErrNoReply = errors.New("no reply message")
+
+// 模拟微信服务器的回调请求, 生成正确签名(和加密)的 *http.Request, 并且验证和解密回复的消息.
+//
+//  cb := mptest.NewCallback(wechatServer, mptest.ModeAES)
+//  var reply response.Text
+//  err := cb.Do(mp.NewWechatServerFrontend(wechatServer, nil), &request.Text{...}, &reply)
+type Callback struct {
+	URL    string   // 回调地址, 默认为 "http://127.0.0.1/"; 可以带上 frontend 需要的查询参数
+	Mode   int      // ModeRaw, ModeCompatible 或者 ModeAES
+	Token  string   // 公众号的 Token
+	AppId  string   // 公众号的 AppId, 加密模式下使用
+	AESKey [32]byte // 加密模式下使用的 AES Key
+}
+
+// 根据 WechatServer 的配置创建 Callback, 使用 CurrentAESKey 加密.
+func NewCallback(ws mp.WechatServer, mode int) *Callback {
+	return &Callback{
+		Mode:   mode,
+		Token:  ws.Token(),
+		AppId:  ws.AppId(),
+		AESKey: ws.CurrentAESKey(),
+	}
+}
+
+// 创建首次验证的 GET 请求, 验证成功的话服务器会原样回复 echostr.
+func (cb *Callback) NewVerifyRequest(echostr string) (r *http.Request, err error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce, err := newNonce()
+	if err != nil {
+		return
+	}
+
+	queryValues := url.Values{}
+	queryValues.Set("signature", util.Sign(cb.Token, timestamp, nonce))
+	queryValues.Set("timestamp", timestamp)
+	queryValues.Set("nonce", nonce)
+	queryValues.Set("echostr", echostr)
+	return cb.newRequest("GET", queryValues, nil)
+}
+
+// 创建推送消息 msg 的 POST 请求.
+//  msg 是消息的数据结构, 比如 *request.Text, *menu.ClickEvent, 经过 encoding/xml marshal 后符合消息的格式.
+func (cb *Callback) NewRequest(msg interface{}) (r *http.Request, err error) {
+	rawXMLMsg, err := xml.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce, err := newNonce()
+	if err != nil {
+		return
+	}
+
+	queryValues := url.Values{}
+	queryValues.Set("signature", util.Sign(cb.Token, timestamp, nonce))
+	queryValues.Set("timestamp", timestamp)
+	queryValues.Set("nonce", nonce)
+
+	if cb.Mode == ModeRaw {
+		return cb.newRequest("POST", queryValues, rawXMLMsg)
+	}
+
+	var header struct {
+		ToUserName string `xml:"ToUserName"`
+	}
+	if err = xml.Unmarshal(rawXMLMsg, &header); err != nil {
+		return
+	}
+
+	random := make([]byte, 16)
+	if _, err = rand.Read(random); err != nil {
+		return
+	}
+	encryptedMsg := util.AESEncryptMsg(random, rawXMLMsg, cb.AppId, cb.AESKey)
+	base64EncryptedMsg := base64.StdEncoding.EncodeToString(encryptedMsg)
+
+	queryValues.Set("encrypt_type", "aes")
+	queryValues.Set("msg_signature", util.MsgSign(cb.Token, timestamp, nonce, base64EncryptedMsg))
+
+	var body []byte
+	switch cb.Mode {
+	case ModeCompatible:
+		// 明文的消息后面追加 Encrypt 节点
+		i := bytes.LastIndex(rawXMLMsg, []byte("</xml>"))
+		if i == -1 {
+			err = errors.New("the root element of message is not <xml>")
+			return
+		}
+		var buf bytes.Buffer
+		buf.Write(rawXMLMsg[:i])
+		buf.WriteString("<Encrypt>")
+		xml.EscapeText(&buf, []byte(base64EncryptedMsg))
+		buf.WriteString("</Encrypt>")
+		buf.Write(rawXMLMsg[i:])
+		body = buf.Bytes()
+
+	case ModeAES:
+		body, err = xml.Marshal(&mp.RequestHttpBody{
+			ToUserName:   header.ToUserName,
+			EncryptedMsg: base64EncryptedMsg,
+		})
+		if err != nil {
+			return
+		}
+
+	default:
+		err = fmt.Errorf("unknown callback mode: %d", cb.Mode)
+		return
+	}
+	return cb.newRequest("POST", queryValues, body)
+}
+
+// 解析服务器回复的 http body 到 v.
+//  明文模式直接解析; 兼容模式和安全模式先验证 MsgSignature, 然后解密再解析.
+func (cb *Callback) ParseResponse(body []byte, v interface{}) (err error) {
+	if cb.Mode == ModeRaw {
+		return xml.Unmarshal(body, v)
+	}
+
+	var responseHttpBody mp.ResponseHttpBody
+	if err = xml.Unmarshal(body, &responseHttpBody); err != nil {
+		return
+	}
+
+	timestamp := strconv.FormatInt(responseHttpBody.Timestamp, 10)
+	msgSignature := util.MsgSign(cb.Token, timestamp, responseHttpBody.Nonce, responseHttpBody.EncryptedMsg)
+	if responseHttpBody.MsgSignature != msgSignature {
+		return &util.SignatureError{Name: "MsgSignature", Input: responseHttpBody.MsgSignature, Local: msgSignature}
+	}
+
+	encryptedMsg, err := base64.StdEncoding.DecodeString(responseHttpBody.EncryptedMsg)
+	if err != nil {
+		return
+	}
+	_, rawXMLMsg, err := util.AESDecryptMsg(encryptedMsg, cb.AppId, cb.AESKey)
+	if err != nil {
+		return
+	}
+	return xml.Unmarshal(rawXMLMsg, v)
+}
+
+// 把 msg 推送给 handler, 并且把回复的消息解析到 reply.
+//  如果 handler 没有回复消息则返回 ErrNoReply; reply == nil 时不解析回复的消息.
+//  默认的 InvalidRequestHandler 什么都不回复, 无效的请求也会返回 ErrNoReply, 测试的时候最好让它返回 4xx.
+func (cb *Callback) Do(handler http.Handler, msg, reply interface{}) (err error) {
+	r, err := cb.NewRequest(msg)
+	if err != nil {
+		return
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		return fmt.Errorf("http.Status: %d, body: %s", w.Code, w.Body.String())
+	}
+	body := bytes.TrimSpace(w.Body.Bytes())
+	if len(body) == 0 || string(body) == "success" {
+		return ErrNoReply
+	}
+	if reply == nil {
+		return
+	}
+	return cb.ParseResponse(body, reply)
+}
+
+func (cb *Callback) newRequest(method string, queryValues url.Values, body []byte) (r *http.Request, err error) {
+	rawURL := cb.URL
+	if rawURL == "" {
+		rawURL = "http://127.0.0.1/"
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+	query := u.Query()
+	for k, vs := range queryValues {
+		query[k] = vs
+	}
+	u.RawQuery = query.Encode()
+
+	r, err = http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	if method == "POST" {
+		r.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	}
+	return
+}
+
+func newNonce() (nonce string, err error) {
+	b := make([]byte, 8)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,89 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mptest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/c77cc/wechat/mp"
+	"github.com/c77cc/wechat/mp/message/request"
+	"github.com/c77cc/wechat/mp/message/response"
+)
+
+func TestCallback(t *testing.T) {
+	handler := mp.MessageHandlerFunc(func(w http.ResponseWriter, r *mp.Request) {
+		if r.MixedMsg.MsgType != request.MsgTypeText {
+			w.Write([]byte("success"))
+			return
+		}
+		text := request.GetText(r.MixedMsg)
+		reply := response.NewText(text.FromUserName, text.ToUserName, text.CreateTime, "echo: "+text.Content)
+		if r.EncryptType == "aes" {
+			mp.WriteAESResponse(w, r, reply)
+		} else {
+			mp.WriteRawResponse(w, r, reply)
+		}
+	})
+	aesKey := bytes.Repeat([]byte("k"), 32)
+	wechatServer := mp.NewDefaultWechatServer("gh_oriid", "token", "appid", aesKey, handler)
+	frontend := mp.NewWechatServerFrontend(wechatServer, invalidRequestHandler)
+
+	msg := &request.Text{
+		CommonMessageHeader: mp.CommonMessageHeader{
+			ToUserName:   "gh_oriid",
+			FromUserName: "openid",
+			CreateTime:   time.Now().Unix(),
+			MsgType:      request.MsgTypeText,
+		},
+		MsgId:   1,
+		Content: "hello",
+	}
+	for _, mode := range []int{ModeRaw, ModeCompatible, ModeAES} {
+		cb := NewCallback(wechatServer, mode)
+
+		var reply response.Text
+		if err := cb.Do(frontend, msg, &reply); err != nil {
+			t.Errorf("mode %d: %v", mode, err)
+			continue
+		}
+		if reply.ToUserName != "openid" || reply.Content != "echo: hello" {
+			t.Errorf("mode %d: unexpected reply: %+v", mode, reply)
+		}
+
+		event := &request.Text{CommonMessageHeader: msg.CommonMessageHeader}
+		event.MsgType = "event"
+		if err := cb.Do(frontend, event, nil); err != ErrNoReply {
+			t.Errorf("mode %d: Do() = %v, want ErrNoReply", mode, err)
+		}
+	}
+
+	// 错误的 Token 不能通过验证
+	cb := NewCallback(wechatServer, ModeAES)
+	cb.Token = "wrong"
+	if err := cb.Do(frontend, msg, nil); err == nil || err == ErrNoReply {
+		t.Error("Do() with wrong token should fail")
+	}
+
+	r, err := NewCallback(wechatServer, ModeRaw).NewVerifyRequest("echostr")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	w := httptest.NewRecorder()
+	frontend.ServeHTTP(w, r)
+	if w.Body.String() != "echostr" {
+		t.Errorf("verify request got %q, want echostr", w.Body.String())
+	}
+}
+
+// 无效的请求返回 400, 这样 Callback.Do 会返回错误.
+var invalidRequestHandler = mp.InvalidRequestHandlerFunc(func(w http.ResponseWriter, r *http.Request, err error) {
+	http.Error(w, err.Error(), http.StatusBadRequest)
+})
@@ -0,0 +1,24 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+// 模拟微信公众平台 api 的测试服务器, 用于离线的集成测试.
+//
+//  srv := mptest.NewServer("appid", "appsecret")
+//  defer srv.Close()
+//
+//  mp.DefaultEndpoint = srv.Endpoint() // DefaultAccessTokenServer 使用 mp.DefaultEndpoint
+//  tokenServer := mp.NewDefaultAccessTokenServer("appid", "appsecret", nil)
+//  wechatClient := mp.NewWechatClient(tokenServer, nil)
+//
+//  // DefaultAccessTokenServer 获取 access_token 之后的 2 秒内不会重新获取, 所以要等 2 秒以上再调用 ExpireTokens,
+//  // 之后的请求返回 42001, WechatClient 会自动刷新 access_token 后重试.
+//  time.Sleep(2 * time.Second)
+//  srv.ExpireTokens()
+//
+// Callback 模拟微信服务器推送给公众号的回调消息, 支持明文模式, 兼容模式和安全模式:
+//
+//  cb := mptest.NewCallback(wechatServer, mptest.ModeAES)
+//  err := cb.Do(mp.NewWechatServerFrontend(wechatServer, nil), &request.Text{...}, &reply)
+package mptest
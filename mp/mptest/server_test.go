@@ -0,0 +1,66 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mptest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/c77cc/wechat/mp"
+	"github.com/c77cc/wechat/mp/user"
+)
+
+func TestServer(t *testing.T) {
+	srv := NewServer("appid", "appsecret")
+	defer srv.Close()
+
+	tokenServer := mp.NewDefaultAccessTokenServer("appid", "appsecret", nil)
+	defer tokenServer.Close()
+	tokenServer.SetEndpoint(srv.Endpoint())
+	clt := mp.NewWechatClient(tokenServer, nil)
+	clt.Endpoint = srv.Endpoint()
+
+	ipList, err := clt.GetCallbackIP()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(ipList) != 1 || ipList[0] != "127.0.0.1" {
+		t.Errorf("GetCallbackIP() = %v", ipList)
+		return
+	}
+
+	if err = srv.AddUser("openid", &user.UserInfo{OpenId: "openid", Nickname: "nickname"}); err != nil {
+		t.Error(err)
+		return
+	}
+	info, err := (user.Client{WechatClient: clt}).UserInfo("openid", "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if info.OpenId != "openid" || info.Nickname != "nickname" {
+		t.Errorf("UserInfo() = %+v", info)
+		return
+	}
+
+	srv.InjectError(45009, "api freq out of limit")
+	if _, err = clt.GetCallbackIP(); err == nil {
+		t.Error("GetCallbackIP() should return the injected error")
+		return
+	}
+
+	// 获取 access_token 2 秒之后让它过期, 客户端自动刷新后重试
+	time.Sleep(2 * time.Second)
+	srv.ExpireTokens()
+	if _, err = clt.GetCallbackIP(); err != nil {
+		t.Errorf("GetCallbackIP() after ExpireTokens failed: %v", err)
+		return
+	}
+	if n := srv.TokenCount(); n != 2 {
+		t.Errorf("TokenCount() = %d, want 2", n)
+	}
+}
@@ -0,0 +1,19 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package mp
+
+import (
+	"github.com/c77cc/wechat/util"
+)
+
+// ServeHTTP 使用的重放保护, 默认为 nil 不做检查.
+//  检查失败的请求交给 InvalidRequestHandler, err 为 util.ErrTimestampOutOfWindow 或 util.ErrNonceReused.
+//  NOTE: 没有加锁, 请确保在初始化阶段设置, 例如:
+//      mp.DefaultReplayGuard = &util.ReplayGuard{
+//          MaxClockSkew: 5 * time.Minute,
+//          NonceStore:   util.NewMemoryDedupStore(100000),
+//      }
+var DefaultReplayGuard *util.ReplayGuard
@@ -114,7 +114,13 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, queryValues url.Values, w
 			// 验证签名
 			msgSignature2 := util.MsgSign(wechatToken, timestampStr, nonce, requestHttpBody.EncryptedMsg)
 			if subtle.ConstantTimeCompare([]byte(msgSignature1), []byte(msgSignature2)) != 1 {
-				err = fmt.Errorf("check msg_signature failed, input: %s, local: %s", msgSignature1, msgSignature2)
+				err = &util.SignatureError{Name: "msg_signature", Input: msgSignature1, Local: msgSignature2}
+				irh.ServeInvalidRequest(w, r, err)
+				return
+			}
+
+			// 重放检查
+			if err = DefaultReplayGuard.Check(ws.AppId(), timestamp, nonce); err != nil {
 				irh.ServeInvalidRequest(w, r, err)
 				return
 			}
@@ -220,7 +226,13 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, queryValues url.Values, w
 
 			signature2 := util.Sign(wechatToken, timestampStr, nonce)
 			if subtle.ConstantTimeCompare([]byte(signature1), []byte(signature2)) != 1 {
-				err = fmt.Errorf("check signature failed, input: %s, local: %s", signature1, signature2)
+				err = &util.SignatureError{Name: "signature", Input: signature1, Local: signature2}
+				irh.ServeInvalidRequest(w, r, err)
+				return
+			}
+
+			// 重放检查
+			if err = DefaultReplayGuard.Check(ws.AppId(), timestamp, nonce); err != nil {
 				irh.ServeInvalidRequest(w, r, err)
 				return
 			}
@@ -312,7 +324,7 @@ func ServeHTTP(w http.ResponseWriter, r *http.Request, queryValues url.Values, w
 
 		signature2 := util.Sign(ws.Token(), timestamp, nonce)
 		if subtle.ConstantTimeCompare([]byte(signature1), []byte(signature2)) != 1 {
-			err := fmt.Errorf("check signature failed, input: %s, local: %s", signature1, signature2)
+			err := &util.SignatureError{Name: "signature", Input: signature1, Local: signature2}
 			irh.ServeInvalidRequest(w, r, err)
 			return
 		}
@@ -10,7 +10,6 @@ package oauth2
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -19,6 +18,7 @@ import (
 	"time"
 
 	"github.com/c77cc/wechat/mp"
+	"github.com/c77cc/wechat/util"
 )
 
 // 构造请求用户授权获取code的地址.
@@ -147,14 +147,14 @@ func (clt *Client) CheckAccessTokenValid() (valid bool, err error) {
 
 	_url := "https://api.weixin.qq.com/sns/auth?access_token=" + url.QueryEscape(clt.AccessToken) +
 		"&openid=" + url.QueryEscape(clt.OpenId)
-	httpResp, err := clt.httpClient().Get(_url)
+	httpResp, err := clt.httpClient().Get(mp.DefaultEndpoint.URL(_url))
 	if err != nil {
 		return
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("http.Status: %s", httpResp.Status)
+		err = &util.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 		return
 	}
 
@@ -190,14 +190,14 @@ func (clt *Client) updateToken(tk *OAuth2Token, url string) (err error) {
 		return errors.New("nil OAuth2Token")
 	}
 
-	httpResp, err := clt.httpClient().Get(url)
+	httpResp, err := clt.httpClient().Get(mp.DefaultEndpoint.URL(url))
 	if err != nil {
 		return
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("http.Status: %s", httpResp.Status)
+		return &util.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 	}
 
 	var result struct {
@@ -18,6 +18,7 @@ import (
 	"strings"
 
 	"github.com/c77cc/wechat/mp"
+	"github.com/c77cc/wechat/util"
 )
 
 const (
@@ -129,14 +130,14 @@ func (clt *Client) UserInfo(lang string) (info *UserInfo, err error) {
 		"?access_token=" + url.QueryEscape(clt.AccessToken) +
 		"&openid=" + url.QueryEscape(clt.OpenId) +
 		"&lang=" + url.QueryEscape(lang)
-	httpResp, err := clt.httpClient().Get(_url)
+	httpResp, err := clt.httpClient().Get(mp.DefaultEndpoint.URL(_url))
 	if err != nil {
 		return
 	}
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("http.Status: %s", httpResp.Status)
+		err = &util.HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 		return
 	}
 
@@ -0,0 +1,122 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package util
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// 回调消息去重的存储接口.
+//  多个进程(或者多台机器)处理同一个公众号的回调时, 共享同一个 DedupStore 才能保证全局去重.
+type DedupStore interface {
+	// 如果 key 不存在(或者已经过期)则添加 key, ttl 时间后过期, 返回 added == true;
+	// 否则不做任何修改, 返回 added == false.
+	Add(key string, ttl time.Duration) (added bool, err error)
+}
+
+// DedupStore 可选实现的接口, 用于处理失败后撤销已经添加的 key, 让重试的消息可以被再次处理.
+type DedupStoreRemover interface {
+	Remove(key string) error
+}
+
+// 需要区分"处理中"和"已经处理完成"两种状态的去重存储, 比如支付结果通知:
+// 用一个 key 标记处理中, 处理成功后再添加另一个 key 标记处理完成.
+type TwoPhaseDedupStore interface {
+	DedupStore
+	DedupStoreRemover
+
+	// 判断 key 是否存在并且没有过期, 不修改 key.
+	Contains(key string) (ok bool, err error)
+}
+
+var (
+	_ DedupStore         = (*MemoryDedupStore)(nil)
+	_ DedupStoreRemover  = (*MemoryDedupStore)(nil)
+	_ TwoPhaseDedupStore = (*MemoryDedupStore)(nil)
+)
+
+// DedupStore 的内存实现, 超过容量的时候淘汰最久没有访问的 key(LRU).
+type MemoryDedupStore struct {
+	mutex    sync.Mutex
+	capacity int
+	list     *list.List               // 越靠前越新
+	elements map[string]*list.Element // key => element, element.Value 为 *dedupStoreEntry
+}
+
+type dedupStoreEntry struct {
+	key       string
+	expiresAt time.Time
+}
+
+// 创建容量为 capacity 的 MemoryDedupStore, capacity <= 0 表示不限制容量.
+func NewMemoryDedupStore(capacity int) *MemoryDedupStore {
+	return &MemoryDedupStore{
+		capacity: capacity,
+		list:     list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+func (store *MemoryDedupStore) Add(key string, ttl time.Duration) (added bool, err error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	timeNow := time.Now()
+	if elem := store.elements[key]; elem != nil {
+		entry := elem.Value.(*dedupStoreEntry)
+		if timeNow.Before(entry.expiresAt) {
+			store.list.MoveToFront(elem)
+			return
+		}
+		entry.expiresAt = timeNow.Add(ttl)
+		store.list.MoveToFront(elem)
+		added = true
+		return
+	}
+
+	store.elements[key] = store.list.PushFront(&dedupStoreEntry{
+		key:       key,
+		expiresAt: timeNow.Add(ttl),
+	})
+	if store.capacity > 0 && store.list.Len() > store.capacity {
+		elem := store.list.Back()
+		store.list.Remove(elem)
+		delete(store.elements, elem.Value.(*dedupStoreEntry).key)
+	}
+	added = true
+	return
+}
+
+func (store *MemoryDedupStore) Remove(key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if elem := store.elements[key]; elem != nil {
+		store.list.Remove(elem)
+		delete(store.elements, key)
+	}
+	return nil
+}
+
+func (store *MemoryDedupStore) Contains(key string) (ok bool, err error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if elem := store.elements[key]; elem != nil {
+		ok = time.Now().Before(elem.Value.(*dedupStoreEntry).expiresAt)
+	}
+	return
+}
+
+// 当前保存的 key 的数量(包括已经过期但是还没有被淘汰的).
+func (store *MemoryDedupStore) Len() int {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.list.Len()
+}
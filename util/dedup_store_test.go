@@ -0,0 +1,91 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryDedupStore(t *testing.T) {
+	store := NewMemoryDedupStore(2)
+
+	if added, _ := store.Add("a", time.Minute); !added {
+		t.Error("first Add(a) should succeed")
+		return
+	}
+	if added, _ := store.Add("a", time.Minute); added {
+		t.Error("second Add(a) should fail")
+		return
+	}
+
+	// 超过容量, 淘汰最久没有访问的 b
+	store.Add("b", time.Minute)
+	store.Add("a", time.Minute)
+	store.Add("c", time.Minute)
+	if n := store.Len(); n != 2 {
+		t.Errorf("Len() == %d, want 2", n)
+		return
+	}
+	if added, _ := store.Add("a", time.Minute); added {
+		t.Error("a should not be evicted")
+		return
+	}
+	if added, _ := store.Add("b", time.Minute); !added {
+		t.Error("b should be evicted")
+		return
+	}
+
+	// 过期之后可以再次添加
+	store.Add("d", time.Millisecond)
+	time.Sleep(time.Millisecond * 5)
+	if added, _ := store.Add("d", time.Minute); !added {
+		t.Error("Add(d) after expiration should succeed")
+		return
+	}
+}
+
+func TestMemoryDedupStoreRemove(t *testing.T) {
+	store := NewMemoryDedupStore(0)
+
+	store.Add("a", time.Minute)
+	if err := store.Remove("a"); err != nil {
+		t.Error(err)
+		return
+	}
+	if n := store.Len(); n != 0 {
+		t.Errorf("Len() == %d, want 0", n)
+		return
+	}
+	if added, _ := store.Add("a", time.Minute); !added {
+		t.Error("Add(a) after Remove should succeed")
+		return
+	}
+	if err := store.Remove("not-exist"); err != nil {
+		t.Error(err)
+		return
+	}
+}
+
+func TestMemoryDedupStoreContains(t *testing.T) {
+	store := NewMemoryDedupStore(0)
+
+	if ok, _ := store.Contains("a"); ok {
+		t.Error("Contains(a) before Add should be false")
+		return
+	}
+	store.Add("a", time.Minute)
+	if ok, _ := store.Contains("a"); !ok {
+		t.Error("Contains(a) after Add should be true")
+		return
+	}
+	store.Add("b", time.Millisecond)
+	time.Sleep(time.Millisecond * 5)
+	if ok, _ := store.Contains("b"); ok {
+		t.Error("Contains(b) after expiration should be false")
+		return
+	}
+}
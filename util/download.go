@@ -7,7 +7,6 @@ package util
 
 import (
 	"errors"
-	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -51,7 +50,7 @@ func downloadToWriter(url string, w io.Writer, httpClient *http.Client) (err err
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("http.Status: %s", httpResp.Status)
+		return &HTTPStatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
 	}
 
 	if _, err = io.Copy(w, httpResp.Body); err != nil {
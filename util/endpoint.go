@@ -0,0 +1,55 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package util
+
+import (
+	"strings"
+)
+
+// 微信服务器官方的 api 地址
+const (
+	DefaultAPIBaseURL     = "https://api.weixin.qq.com"     // 公众号
+	DefaultCorpAPIBaseURL = "https://qyapi.weixin.qq.com"   // 企业号
+	DefaultMchAPIBaseURL  = "https://api.mch.weixin.qq.com" // 微信支付
+)
+
+// 微信服务器 api 地址的配置, 可以把请求重定向到本地的测试服务器, 代理或者其他地区的网关.
+//  字段为空表示使用官方的地址, 例如:
+//      endpoint := &util.Endpoint{
+//          APIBaseURL: "http://127.0.0.1:8080",
+//      }
+//  那么 "https://api.weixin.qq.com/cgi-bin/user/info?access_token=" 会被转换为
+//  "http://127.0.0.1:8080/cgi-bin/user/info?access_token=".
+type Endpoint struct {
+	APIBaseURL     string // 公众号 api 地址, 默认为 DefaultAPIBaseURL
+	CorpAPIBaseURL string // 企业号 api 地址, 默认为 DefaultCorpAPIBaseURL
+	MchAPIBaseURL  string // 微信支付 api 地址, 默认为 DefaultMchAPIBaseURL
+}
+
+// 把官方的 api 地址 rawURL 转换为 Endpoint 配置的地址.
+//  如果 rawURL 不是官方的 api 地址或者 e == nil, 则原样返回.
+func (e *Endpoint) URL(rawURL string) string {
+	if e == nil {
+		return rawURL
+	}
+	if u, ok := replaceBaseURL(rawURL, DefaultAPIBaseURL, e.APIBaseURL); ok {
+		return u
+	}
+	if u, ok := replaceBaseURL(rawURL, DefaultCorpAPIBaseURL, e.CorpAPIBaseURL); ok {
+		return u
+	}
+	if u, ok := replaceBaseURL(rawURL, DefaultMchAPIBaseURL, e.MchAPIBaseURL); ok {
+		return u
+	}
+	return rawURL
+}
+
+func replaceBaseURL(rawURL, defaultBaseURL, baseURL string) (u string, ok bool) {
+	if baseURL == "" || !strings.HasPrefix(rawURL, defaultBaseURL+"/") {
+		return
+	}
+	return strings.TrimSuffix(baseURL, "/") + rawURL[len(defaultBaseURL):], true
+}
@@ -0,0 +1,37 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package util
+
+import (
+	"testing"
+)
+
+func TestEndpointURL(t *testing.T) {
+	endpoint := &Endpoint{
+		APIBaseURL:    "http://127.0.0.1:8080/",
+		MchAPIBaseURL: "http://127.0.0.1:8081",
+	}
+
+	tests := []struct {
+		rawURL string
+		want   string
+	}{
+		{"https://api.weixin.qq.com/cgi-bin/user/info?access_token=", "http://127.0.0.1:8080/cgi-bin/user/info?access_token="},
+		{"https://api.mch.weixin.qq.com/pay/unifiedorder", "http://127.0.0.1:8081/pay/unifiedorder"},
+		{"https://qyapi.weixin.qq.com/cgi-bin/gettoken?corpid=", "https://qyapi.weixin.qq.com/cgi-bin/gettoken?corpid="},
+		{"https://mp.weixin.qq.com/cgi-bin/showqrcode?ticket=", "https://mp.weixin.qq.com/cgi-bin/showqrcode?ticket="},
+	}
+	for _, test := range tests {
+		if have := endpoint.URL(test.rawURL); have != test.want {
+			t.Errorf("URL(%q) = %q, want %q", test.rawURL, have, test.want)
+		}
+	}
+
+	var nilEndpoint *Endpoint
+	if have := nilEndpoint.URL(tests[0].rawURL); have != tests[0].rawURL {
+		t.Errorf("nil Endpoint should not change url, have %q", have)
+	}
+}
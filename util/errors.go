@@ -0,0 +1,58 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package util
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 错误的分类, 用 errors.Is 判断, 例如:
+//  if errors.Is(err, util.ErrRateLimited) {
+//      // 稍后重试
+//  }
+//  mp.Error, corp.Error 根据全局返回码归类, 见 mp.ErrCodeText, corp.ErrCodeText.
+var (
+	ErrInvalidCredential = errors.New("invalid credential")              // access_token, appid, secret 等凭证无效或者过期
+	ErrRateLimited       = errors.New("api rate limited")                // 接口调用超过限制, 比如 45009
+	ErrNotSubscribed     = errors.New("receiver not subscribed")         // 需要接收者关注, 43004
+	ErrResponseTimeout   = errors.New("response time limit exceeded")    // 回复时间超过限制, 比如超过 48 小时不能再发送客服消息, 45015
+	ErrSignatureMismatch = errors.New("signature mismatch")              // 签名验证失败, 见 SignatureError
+	ErrHTTPStatus        = errors.New("unexpected http response status") // http 状态码不是 200, 见 HTTPStatusError
+)
+
+// http 响应的状态码不是 200.
+//
+//	errors.Is(err, ErrHTTPStatus) == true, 具体的状态码通过 errors.As 获取.
+type HTTPStatusError struct {
+	StatusCode int    // 比如 502
+	Status     string // 比如 "502 Bad Gateway"
+}
+
+func (e *HTTPStatusError) Error() string {
+	return "http.Status: " + e.Status
+}
+
+func (e *HTTPStatusError) Is(target error) bool {
+	return target == ErrHTTPStatus
+}
+
+// 签名验证失败.
+//
+//	errors.Is(err, ErrSignatureMismatch) == true.
+type SignatureError struct {
+	Name  string // 签名的参数名, 比如 signature, msg_signature, sign
+	Input string // 请求(响应)里的签名
+	Local string // 本地计算的签名
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("check %s failed, input: %s, local: %s", e.Name, e.Input, e.Local)
+}
+
+func (e *SignatureError) Is(target error) bool {
+	return target == ErrSignatureMismatch
+}
@@ -0,0 +1,36 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorsIs(t *testing.T) {
+	var err error = &HTTPStatusError{StatusCode: 502, Status: "502 Bad Gateway"}
+	err = fmt.Errorf("get access_token: %w", err)
+	if !errors.Is(err, ErrHTTPStatus) {
+		t.Error("errors.Is(err, ErrHTTPStatus) == false")
+		return
+	}
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != 502 {
+		t.Errorf("errors.As failed: %v", err)
+		return
+	}
+
+	err = &SignatureError{Name: "signature", Input: "a", Local: "b"}
+	if !errors.Is(err, ErrSignatureMismatch) {
+		t.Error("errors.Is(err, ErrSignatureMismatch) == false")
+		return
+	}
+	if errors.Is(err, ErrHTTPStatus) {
+		t.Error("errors.Is(err, ErrHTTPStatus) == true")
+		return
+	}
+}
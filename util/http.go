@@ -0,0 +1,39 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package util
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// 和 http.Client.Get 一样, 但是请求可以通过 ctx 取消或者设置超时.
+//  如果 ctx == nil 则用 context.Background().
+func HttpGetContext(ctx context.Context, clt *http.Client, url string) (resp *http.Response, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return
+	}
+	return clt.Do(req)
+}
+
+// 和 http.Client.Post 一样, 但是请求可以通过 ctx 取消或者设置超时.
+//  如果 ctx == nil 则用 context.Background().
+func HttpPostContext(ctx context.Context, clt *http.Client, url, bodyType string, body io.Reader) (resp *http.Response, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", bodyType)
+	return clt.Do(req)
+}
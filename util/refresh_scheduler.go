@@ -0,0 +1,188 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package util
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// access_token, ticket 等的刷新策略.
+//  成功获取后在有效期过去 [1-AheadRatio-JitterRatio, 1-AheadRatio] 的时候刷新, 避免多个实例同时刷新;
+//  刷新失败后按 Retry 指数退避重试, 直到成功为止.
+type RefreshPolicy struct {
+	AheadRatio  float64     // 提前刷新的比例, 比如 0.1 表示在有效期还剩 10% 的时候刷新
+	JitterRatio float64     // 随机抖动的比例
+	Retry       RetryPolicy // 刷新失败后的重试等待时间, 只使用 BaseDelay 和 MaxDelay
+}
+
+// 默认的刷新策略, 没有加锁, 请确保在初始化阶段修改!
+var DefaultRefreshPolicy = RefreshPolicy{
+	AheadRatio:  0.1,
+	JitterRatio: 0.1,
+	Retry: RetryPolicy{
+		BaseDelay: time.Second,
+		MaxDelay:  5 * time.Minute,
+	},
+}
+
+// 一次刷新的结果.
+type RefreshEvent struct {
+	Key         string        // 刷新的对象, 比如 "mp/access_token/" + appId
+	Time        time.Time     // 刷新结束的时间
+	ExpiresIn   time.Duration // 成功时为新的有效期
+	Err         error         // 失败时的错误
+	Failures    int           // 连续失败的次数, 成功时为 0
+	NextRefresh time.Time     // 下一次刷新的时间
+}
+
+type RefreshHookFunc func(event *RefreshEvent)
+
+// 全局的刷新事件回调, 在 RefreshScheduler 没有设置 hook 的时候调用, 没有加锁, 请确保在初始化阶段修改!
+//  回调在刷新的 goroutine 里同步执行, 不要阻塞.
+var DefaultRefreshHook RefreshHookFunc
+
+// 执行一次刷新, 返回新的有效期.
+type RefreshFunc func() (expiresIn time.Duration, err error)
+
+// 定时刷新 access_token, ticket 等的调度器, 每个 RefreshScheduler 有一个后台 goroutine, 用完后需要调用 Close.
+//  刚创建的时候不会刷新, 第一次刷新由调用方完成并通过 Refreshed 告知结果, 之后按照 RefreshPolicy 定时刷新.
+type RefreshScheduler struct {
+	key     string
+	refresh RefreshFunc
+	policy  RefreshPolicy
+
+	mutex    sync.Mutex
+	failures int
+	next     time.Duration // 调用方刷新后计算出来的下一次刷新的等待时间
+	hook     RefreshHookFunc
+
+	resetChan  chan struct{} // 通知后台 goroutine 使用 next 重新计时
+	closedChan chan struct{}
+	doneChan   chan struct{}
+	closeOnce  sync.Once
+}
+
+// 创建并启动一个 RefreshScheduler, 使用 DefaultRefreshPolicy.
+func NewRefreshScheduler(key string, refresh RefreshFunc) *RefreshScheduler {
+	s := &RefreshScheduler{
+		key:        key,
+		refresh:    refresh,
+		policy:     DefaultRefreshPolicy,
+		resetChan:  make(chan struct{}, 1),
+		closedChan: make(chan struct{}),
+		doneChan:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// 设置刷新事件的回调, hook == nil 的时候使用 DefaultRefreshHook.
+func (s *RefreshScheduler) SetHook(hook RefreshHookFunc) {
+	s.mutex.Lock()
+	s.hook = hook
+	s.mutex.Unlock()
+}
+
+// 调用方自己刷新之后调用, 告知结果并重新安排下一次刷新, 不会阻塞.
+func (s *RefreshScheduler) Refreshed(expiresIn time.Duration, err error) {
+	next := s.done(expiresIn, err)
+
+	s.mutex.Lock()
+	s.next = next
+	s.mutex.Unlock()
+
+	select {
+	case s.resetChan <- struct{}{}:
+	default:
+	}
+}
+
+// 停止后台 goroutine, 等待其退出后返回, 可以重复调用.
+func (s *RefreshScheduler) Close() {
+	s.closeOnce.Do(func() { close(s.closedChan) })
+	<-s.doneChan
+}
+
+func (s *RefreshScheduler) run() {
+	defer close(s.doneChan)
+
+	var timer *time.Timer
+	var timerChan <-chan time.Time // nil 表示还没有安排刷新
+	schedule := func(d time.Duration) {
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.NewTimer(d)
+		timerChan = timer.C
+	}
+
+	for {
+		select {
+		case <-s.closedChan:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+
+		case <-s.resetChan:
+			s.mutex.Lock()
+			next := s.next
+			s.mutex.Unlock()
+			schedule(next)
+
+		case <-timerChan:
+			schedule(s.done(s.refresh()))
+		}
+	}
+}
+
+// 记录一次刷新的结果, 通知 hook, 返回距离下一次刷新的时间.
+func (s *RefreshScheduler) done(expiresIn time.Duration, err error) (next time.Duration) {
+	s.mutex.Lock()
+	if err != nil {
+		s.failures++
+		next = s.policy.Retry.Backoff(s.failures)
+	} else {
+		s.failures = 0
+		next = s.policy.nextRefresh(expiresIn)
+	}
+	failures := s.failures
+	hook := s.hook
+	s.mutex.Unlock()
+
+	if hook == nil {
+		hook = DefaultRefreshHook
+	}
+	if hook != nil {
+		now := time.Now()
+		hook(&RefreshEvent{
+			Key:         s.key,
+			Time:        now,
+			ExpiresIn:   expiresIn,
+			Err:         err,
+			Failures:    failures,
+			NextRefresh: now.Add(next),
+		})
+	}
+	return
+}
+
+// 有效期为 expiresIn 的时候, 距离下一次刷新的时间.
+func (p *RefreshPolicy) nextRefresh(expiresIn time.Duration) time.Duration {
+	if expiresIn <= 0 {
+		return p.Retry.Backoff(1)
+	}
+	next := expiresIn - time.Duration(float64(expiresIn)*p.AheadRatio)
+	if jitter := int64(float64(expiresIn) * p.JitterRatio); jitter > 0 {
+		next -= time.Duration(rand.Int63n(jitter + 1))
+	}
+	if next <= 0 {
+		next = expiresIn / 2
+	}
+	return next
+}
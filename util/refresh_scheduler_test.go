@@ -0,0 +1,71 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package util
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRefreshPolicyNextRefresh(t *testing.T) {
+	policy := &RefreshPolicy{AheadRatio: 0.1, JitterRatio: 0.1}
+	for i := 0; i < 100; i++ {
+		next := policy.nextRefresh(time.Hour)
+		if next < time.Minute*48 || next > time.Minute*54 {
+			t.Errorf("nextRefresh(1h) == %v, out of range", next)
+			return
+		}
+	}
+}
+
+func TestRefreshScheduler(t *testing.T) {
+	calls := 0
+	s := NewRefreshScheduler("test", func() (time.Duration, error) {
+		calls++
+		if calls <= 2 {
+			return 0, errors.New("temporary error")
+		}
+		return time.Hour, nil
+	})
+	s.policy = RefreshPolicy{
+		Retry: RetryPolicy{BaseDelay: time.Millisecond * 10, MaxDelay: time.Millisecond * 20},
+	}
+	events := make(chan *RefreshEvent, 10)
+	s.SetHook(func(event *RefreshEvent) { events <- event })
+
+	s.Refreshed(time.Millisecond*10, nil)
+	if event := <-events; event.Err != nil || event.ExpiresIn != time.Millisecond*10 {
+		t.Errorf("unexpected event: %+v", event)
+		return
+	}
+	for failures := 1; failures <= 2; failures++ {
+		select {
+		case event := <-events:
+			if event.Err == nil || event.Failures != failures {
+				t.Errorf("unexpected event: %+v", event)
+				return
+			}
+		case <-time.After(time.Second):
+			t.Error("timeout waiting for refresh")
+			return
+		}
+	}
+	select {
+	case event := <-events:
+		if event.Err != nil || event.Failures != 0 || event.ExpiresIn != time.Hour {
+			t.Errorf("unexpected event: %+v", event)
+			return
+		}
+	case <-time.After(time.Second):
+		t.Error("timeout waiting for refresh")
+		return
+	}
+
+	s.Close()
+	s.Close()
+	s.Refreshed(time.Hour, nil) // 关闭后也不会阻塞
+}
@@ -0,0 +1,62 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package util
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrTimestampOutOfWindow = errors.New("timestamp is out of the allowed clock skew")
+	ErrNonceReused          = errors.New("nonce has been used")
+)
+
+// 回调请求的重放保护.
+//  签名验证通过后检查 timestamp 和服务器时间的偏差是否超过 MaxClockSkew,
+//  以及 timestamp + nonce 是否已经出现过, 被截获的请求无法再次使用.
+type ReplayGuard struct {
+	MaxClockSkew time.Duration // timestamp 允许的最大偏差, <= 0 表示不检查
+	NonceStore   DedupStore    // 已经使用过的 nonce, nil 表示不检查
+}
+
+// nonce 在 NonceStore 里的保存时间, 超过 MaxClockSkew 的请求已经被时间戳检查拒绝了.
+//  MaxClockSkew <= 0 的时候使用 defaultReplayNonceTTL.
+const defaultReplayNonceTTL = time.Minute * 10
+
+// 检查 timestamp 和 nonce, 通过返回 nil, 否则返回 ErrTimestampOutOfWindow 或 ErrNonceReused
+// (或者 NonceStore 的错误). g == nil 的时候不做任何检查.
+//  key 用于区分不同的公众号(企业号应用, 套件), 比如 AppId.
+func (g *ReplayGuard) Check(key string, timestamp int64, nonce string) (err error) {
+	if g == nil {
+		return
+	}
+
+	ttl := defaultReplayNonceTTL
+	if g.MaxClockSkew > 0 {
+		skew := time.Now().Sub(time.Unix(timestamp, 0))
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > g.MaxClockSkew {
+			return ErrTimestampOutOfWindow
+		}
+		ttl = g.MaxClockSkew * 2
+	}
+
+	if g.NonceStore == nil {
+		return
+	}
+	added, err := g.NonceStore.Add("nonce/"+key+"/"+strconv.FormatInt(timestamp, 10)+"/"+nonce, ttl)
+	if err != nil {
+		return
+	}
+	if !added {
+		return ErrNonceReused
+	}
+	return
+}
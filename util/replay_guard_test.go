@@ -0,0 +1,46 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReplayGuard(t *testing.T) {
+	var nilGuard *ReplayGuard
+	if err := nilGuard.Check("appid", 0, "nonce"); err != nil {
+		t.Errorf("nil ReplayGuard should not check, got %v", err)
+		return
+	}
+
+	guard := &ReplayGuard{
+		MaxClockSkew: time.Minute,
+		NonceStore:   NewMemoryDedupStore(0),
+	}
+	timestamp := time.Now().Unix()
+
+	if err := guard.Check("appid", timestamp-120, "nonce1"); err != ErrTimestampOutOfWindow {
+		t.Errorf("got %v, want ErrTimestampOutOfWindow", err)
+		return
+	}
+	if err := guard.Check("appid", timestamp+120, "nonce1"); err != ErrTimestampOutOfWindow {
+		t.Errorf("got %v, want ErrTimestampOutOfWindow", err)
+		return
+	}
+	if err := guard.Check("appid", timestamp, "nonce1"); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := guard.Check("appid", timestamp, "nonce1"); err != ErrNonceReused {
+		t.Errorf("got %v, want ErrNonceReused", err)
+		return
+	}
+	if err := guard.Check("appid2", timestamp, "nonce1"); err != nil {
+		t.Error(err)
+		return
+	}
+}
@@ -0,0 +1,113 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package util
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// 默认可以重试的全局返回码: -1 系统繁忙, 45009 接口调用超过限制, 45011 API 调用太频繁.
+var DefaultRetryErrCodes = []int64{-1, 45009, 45011}
+
+// 临时性错误(系统繁忙, 接口调用超过限制, http 5xx)的重试策略.
+//  重试前等待的时间按 BaseDelay * 2^(n-1) 指数增长, 不超过 MaxDelay, 并且加上随机抖动.
+//  被 NonIdempotent 标记的请求不会重试, 比如群发消息, 支付下单等.
+//
+//  policy := &util.RetryPolicy{
+//      MaxAttempts: 3,
+//      BaseDelay:   100 * time.Millisecond,
+//      MaxDelay:    2 * time.Second,
+//      ErrCodes:    util.DefaultRetryErrCodes,
+//  }
+type RetryPolicy struct {
+	MaxAttempts int           // 最多请求的次数(包括第一次), <= 1 表示不重试
+	BaseDelay   time.Duration // 第一次重试前等待的时间
+	MaxDelay    time.Duration // 等待时间的上限, <= 0 表示不限制
+	ErrCodes    []int64       // 可以重试的 errcode
+	NoRetry5xx  bool          // 为 true 时 http 5xx 不重试
+}
+
+type nonIdempotentKey struct{}
+
+// 返回一个标记为"非幂等"的 ctx, 用这个 ctx 发起的请求不会被 RetryPolicy 重试.
+func NonIdempotent(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, nonIdempotentKey{}, true)
+}
+
+// 判断 ctx 是否被 NonIdempotent 标记过.
+func IsNonIdempotent(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	v, _ := ctx.Value(nonIdempotentKey{}).(bool)
+	return v
+}
+
+// 判断第 attempt 次请求返回 errCode 后是否需要重试, p == nil 的时候返回 false.
+func (p *RetryPolicy) ShouldRetry(ctx context.Context, attempt int, errCode int64) bool {
+	if !p.canRetry(ctx, attempt) {
+		return false
+	}
+	for _, code := range p.ErrCodes {
+		if code == errCode {
+			return true
+		}
+	}
+	return false
+}
+
+// 判断第 attempt 次请求返回 http 状态码 statusCode 后是否需要重试, p == nil 的时候返回 false.
+func (p *RetryPolicy) ShouldRetryHTTPStatus(ctx context.Context, attempt int, statusCode int) bool {
+	if !p.canRetry(ctx, attempt) {
+		return false
+	}
+	return !p.NoRetry5xx && statusCode >= 500 && statusCode <= 599
+}
+
+func (p *RetryPolicy) canRetry(ctx context.Context, attempt int) bool {
+	return p != nil && attempt < p.MaxAttempts && !IsNonIdempotent(ctx)
+}
+
+// 第 attempt 次请求失败后, 等待重试的时间.
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	if p == nil || p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	// 抖动: [delay/2, delay)
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// 等待 Backoff(attempt) 的时间, ctx 被取消的时候立即返回 ctx.Err().
+func (p *RetryPolicy) Wait(ctx context.Context, attempt int) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timer := time.NewTimer(p.Backoff(attempt))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,57 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package util
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	var nilPolicy *RetryPolicy
+	if nilPolicy.ShouldRetry(nil, 1, -1) {
+		t.Error("nil RetryPolicy should not retry")
+		return
+	}
+
+	policy := &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond * 100,
+		MaxDelay:    time.Millisecond * 300,
+		ErrCodes:    DefaultRetryErrCodes,
+	}
+	ctx := context.Background()
+
+	if !policy.ShouldRetry(ctx, 1, 45009) || !policy.ShouldRetry(ctx, 2, -1) {
+		t.Error("45009 and -1 should be retried")
+		return
+	}
+	if policy.ShouldRetry(ctx, 3, -1) {
+		t.Error("should not retry after MaxAttempts")
+		return
+	}
+	if policy.ShouldRetry(ctx, 1, 40003) {
+		t.Error("40003 should not be retried")
+		return
+	}
+	if !policy.ShouldRetryHTTPStatus(ctx, 1, 502) || policy.ShouldRetryHTTPStatus(ctx, 1, 404) {
+		t.Error("only 5xx should be retried")
+		return
+	}
+	if policy.ShouldRetry(NonIdempotent(ctx), 1, -1) || policy.ShouldRetryHTTPStatus(NonIdempotent(ctx), 1, 502) {
+		t.Error("non-idempotent request should not be retried")
+		return
+	}
+
+	for attempt := 1; attempt <= 5; attempt++ {
+		d := policy.Backoff(attempt)
+		if d < policy.BaseDelay/2 || d > policy.MaxDelay {
+			t.Errorf("Backoff(%d) == %v, out of range", attempt, d)
+			return
+		}
+	}
+}
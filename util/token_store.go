@@ -0,0 +1,188 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package util
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrTokenStoreTimeout = errors.New("timeout waiting for token refresh by other process")
+
+// access_token, jsapi_ticket 等凭证的共享存储接口.
+//  多个进程(或者多台机器)共享同一个 TokenStore 的时候, 同一个 key 在同一时刻只有一个进程
+//  去微信服务器刷新, 其他进程直接使用刷新后的结果, 避免相互覆盖导致对方的凭证失效.
+type TokenStore interface {
+	// 获取 key 对应的 token 及其过期时间.
+	//  如果不存在则返回 token == "", err == nil.
+	Get(key string) (token string, expiresAt time.Time, err error)
+
+	// 当 key 当前对应的 token 等于 old 的时候才把它设置为 new, 返回是否设置成功.
+	CompareAndSet(key, old, new string, expiresAt time.Time) (swapped bool, err error)
+
+	// 尝试获取 key 的刷新租约, 租约在 lease 时间后自动失效.
+	//  获取成功返回 acquired == true, 刷新完成后需要调用 unlock 释放租约.
+	TryLock(key string, lease time.Duration) (unlock func(), acquired bool, err error)
+}
+
+const (
+	tokenStoreLease        = time.Second * 90       // 刷新租约的时长, 要大于获取 token 的 http 请求的超时时间(默认 60 秒)
+	tokenStorePollInterval = time.Millisecond * 100 // 等待其他进程刷新时轮询的间隔
+)
+
+// 通过 TokenStore 协调多个进程的刷新.
+//  stale 是调用者当前认为无效的 token(没有则为 ""), 如果 store 里有一个不等于 stale 并且没有过期的 token,
+//  则直接返回它; 否则获取刷新租约后调用 fetch 到微信服务器获取新的 token 并写入 store.
+//  如果其他进程持有租约, 则等待它刷新完成.
+func RefreshTokenWithStore(store TokenStore, key, stale string,
+	fetch func() (token string, expiresIn int64, err error)) (token string, expiresIn int64, err error) {
+
+	deadline := time.Now().Add(tokenStoreLease * 2)
+	for {
+		var expiresAt time.Time
+		if token, expiresAt, err = store.Get(key); err != nil {
+			return
+		}
+		if token != "" && token != stale {
+			if expiresIn = int64(expiresAt.Sub(time.Now()) / time.Second); expiresIn > 0 {
+				return
+			}
+		}
+
+		unlock, acquired, err := store.TryLock(key, tokenStoreLease)
+		if err != nil {
+			return "", 0, err
+		}
+		if acquired {
+			defer unlock()
+			return refreshTokenLocked(store, key, stale, fetch)
+		}
+
+		if time.Now().After(deadline) {
+			return "", 0, ErrTokenStoreTimeout
+		}
+		time.Sleep(tokenStorePollInterval)
+	}
+}
+
+// 持有租约的情况下刷新 token.
+func refreshTokenLocked(store TokenStore, key, stale string,
+	fetch func() (token string, expiresIn int64, err error)) (token string, expiresIn int64, err error) {
+
+	// 获取租约之前其他进程可能已经刷新过了, 再检查一次
+	current, expiresAt, err := store.Get(key)
+	if err != nil {
+		return
+	}
+	if current != "" && current != stale {
+		if expiresIn = int64(expiresAt.Sub(time.Now()) / time.Second); expiresIn > 0 {
+			token = current
+			return
+		}
+	}
+
+	if token, expiresIn, err = fetch(); err != nil {
+		return
+	}
+	swapped, err := store.CompareAndSet(key, current, token, time.Now().Add(time.Duration(expiresIn)*time.Second))
+	if err != nil || swapped {
+		return
+	}
+
+	// 租约过期后其他进程也刷新了 token, 以 store 里的为准, 保证所有进程使用同一个 token
+	stored, expiresAt, err := store.Get(key)
+	if err != nil {
+		return "", 0, err
+	}
+	if stored != "" && stored != stale {
+		if n := int64(expiresAt.Sub(time.Now()) / time.Second); n > 0 {
+			return stored, n, nil
+		}
+	}
+	return
+}
+
+var _ TokenStore = (*MemoryTokenStore)(nil)
+
+// TokenStore 的内存实现, 用于单进程内多个 token 中控服务器共享.
+type MemoryTokenStore struct {
+	mutex   sync.Mutex
+	entries map[string]*tokenStoreEntry
+}
+
+type tokenStoreEntry struct {
+	Token          string    `json:"token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	LeaseId        int64     `json:"lease_id,omitempty"`
+	LeaseExpiresAt time.Time `json:"lease_expires_at,omitempty"`
+}
+
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{
+		entries: make(map[string]*tokenStoreEntry),
+	}
+}
+
+func (store *MemoryTokenStore) Get(key string) (token string, expiresAt time.Time, err error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if entry := store.entries[key]; entry != nil {
+		token = entry.Token
+		expiresAt = entry.ExpiresAt
+	}
+	return
+}
+
+func (store *MemoryTokenStore) CompareAndSet(key, old, new string, expiresAt time.Time) (swapped bool, err error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry := store.entries[key]
+	if entry == nil {
+		entry = &tokenStoreEntry{}
+		store.entries[key] = entry
+	}
+	if entry.Token != old {
+		return
+	}
+	entry.Token = new
+	entry.ExpiresAt = expiresAt
+	swapped = true
+	return
+}
+
+func (store *MemoryTokenStore) TryLock(key string, lease time.Duration) (unlock func(), acquired bool, err error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry := store.entries[key]
+	if entry == nil {
+		entry = &tokenStoreEntry{}
+		store.entries[key] = entry
+	}
+
+	timeNow := time.Now()
+	if entry.LeaseId != 0 && timeNow.Before(entry.LeaseExpiresAt) {
+		return
+	}
+	leaseId := timeNow.UnixNano()
+	entry.LeaseId = leaseId
+	entry.LeaseExpiresAt = timeNow.Add(lease)
+
+	unlock = func() {
+		store.mutex.Lock()
+		defer store.mutex.Unlock()
+
+		if entry.LeaseId == leaseId {
+			entry.LeaseId = 0
+			entry.LeaseExpiresAt = time.Time{}
+		}
+	}
+	acquired = true
+	return
+}
@@ -0,0 +1,138 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package util
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+var _ TokenStore = (*FileTokenStore)(nil)
+
+// TokenStore 的文件实现, 用于同一台机器上的多个进程共享.
+//  每个 key 对应 dir 目录下的一个文件, 所有的读写都在 flock 保护下进行.
+type FileTokenStore struct {
+	dir string
+}
+
+// 创建一个新的 FileTokenStore, 如果 dir 不存在则自动创建.
+func NewFileTokenStore(dir string) (store *FileTokenStore, err error) {
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return
+	}
+	store = &FileTokenStore{
+		dir: dir,
+	}
+	return
+}
+
+func (store *FileTokenStore) filename(key string) string {
+	hashsum := sha1.Sum([]byte(key))
+	return filepath.Join(store.dir, hex.EncodeToString(hashsum[:])+".token")
+}
+
+// 在文件锁保护下读取 key 对应的 entry, 调用 fn, 如果 fn 返回 modified == true 则写回文件.
+func (store *FileTokenStore) update(key string, fn func(entry *tokenStoreEntry) (modified bool)) (err error) {
+	file, err := os.OpenFile(store.filename(key), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return
+	}
+	defer syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return
+	}
+
+	var entry tokenStoreEntry
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &entry); err != nil {
+			return
+		}
+	}
+
+	if !fn(&entry) {
+		return
+	}
+
+	if data, err = json.Marshal(&entry); err != nil {
+		return
+	}
+	if err = file.Truncate(0); err != nil {
+		return
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+	_, err = file.Write(data)
+	return
+}
+
+func (store *FileTokenStore) Get(key string) (token string, expiresAt time.Time, err error) {
+	err = store.update(key, func(entry *tokenStoreEntry) bool {
+		token = entry.Token
+		expiresAt = entry.ExpiresAt
+		return false
+	})
+	return
+}
+
+func (store *FileTokenStore) CompareAndSet(key, old, new string, expiresAt time.Time) (swapped bool, err error) {
+	err = store.update(key, func(entry *tokenStoreEntry) bool {
+		if entry.Token != old {
+			return false
+		}
+		entry.Token = new
+		entry.ExpiresAt = expiresAt
+		swapped = true
+		return true
+	})
+	return
+}
+
+func (store *FileTokenStore) TryLock(key string, lease time.Duration) (unlock func(), acquired bool, err error) {
+	timeNow := time.Now()
+	leaseId := timeNow.UnixNano() ^ int64(os.Getpid())<<48
+
+	err = store.update(key, func(entry *tokenStoreEntry) bool {
+		if entry.LeaseId != 0 && timeNow.Before(entry.LeaseExpiresAt) {
+			return false
+		}
+		entry.LeaseId = leaseId
+		entry.LeaseExpiresAt = timeNow.Add(lease)
+		acquired = true
+		return true
+	})
+	if err != nil || !acquired {
+		return
+	}
+
+	unlock = func() {
+		store.update(key, func(entry *tokenStoreEntry) bool {
+			if entry.LeaseId != leaseId {
+				return false
+			}
+			entry.LeaseId = 0
+			entry.LeaseExpiresAt = time.Time{}
+			return true
+		})
+	}
+	return
+}
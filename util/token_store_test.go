@@ -0,0 +1,102 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/c77cc/wechat for the canonical source repository
+// @license     https://github.com/c77cc/wechat/blob/master/LICENSE
+// @authors     c77cc(c77cc@gmail.com)
+
+package util
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokenWithStore(t *testing.T) {
+	store := NewMemoryTokenStore()
+
+	var fetchCount int32
+	fetch := func() (string, int64, error) {
+		atomic.AddInt32(&fetchCount, 1)
+		time.Sleep(time.Millisecond * 50)
+		return "token1", 7200, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			token, expiresIn, err := RefreshTokenWithStore(store, "key", "", fetch)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if token != "token1" || expiresIn <= 0 {
+				t.Errorf("unexpected token %q, expires_in %d", token, expiresIn)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&fetchCount); n != 1 {
+		t.Errorf("fetch called %d times, want 1", n)
+		return
+	}
+
+	// token1 被认为无效的时候需要重新获取
+	token, _, err := RefreshTokenWithStore(store, "key", "token1", func() (string, int64, error) {
+		return "token2", 7200, nil
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if token != "token2" {
+		t.Errorf("got token %q, want token2", token)
+		return
+	}
+}
+
+func TestMemoryTokenStoreTryLock(t *testing.T) {
+	store := NewMemoryTokenStore()
+
+	unlock, acquired, err := store.TryLock("key", time.Minute)
+	if err != nil || !acquired {
+		t.Error("first TryLock should succeed")
+		return
+	}
+	if _, acquired, _ = store.TryLock("key", time.Minute); acquired {
+		t.Error("second TryLock should fail before unlock")
+		return
+	}
+	unlock()
+	if _, acquired, _ = store.TryLock("key", time.Minute); !acquired {
+		t.Error("TryLock should succeed after unlock")
+		return
+	}
+}
+
+func TestRefreshTokenWithStoreLostRace(t *testing.T) {
+	store := NewMemoryTokenStore()
+
+	// fetch 期间其他进程写入了新的 token
+	token, expiresIn, err := RefreshTokenWithStore(store, "key", "", func() (string, int64, error) {
+		if _, err := store.CompareAndSet("key", "", "other", time.Now().Add(time.Hour)); err != nil {
+			return "", 0, err
+		}
+		return "mine", 7200, nil
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if token != "other" || expiresIn <= 0 || expiresIn > 3600 {
+		t.Errorf("got token %q, expires_in %d, want the stored token", token, expiresIn)
+		return
+	}
+	if stored, _, _ := store.Get("key"); stored != "other" {
+		t.Errorf("stored token %q, want other", stored)
+	}
+}